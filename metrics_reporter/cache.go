@@ -0,0 +1,139 @@
+package metrics_reporter
+
+import (
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+var cacheSteps = []string{
+	"read-hit", "read-miss", "read-mshr-hit",
+	"write-hit", "write-miss", "write-mshr-hit",
+}
+
+type cacheLatencyTracer struct {
+	tracer *histogramTracer
+	cache  tracing.NamedHookable
+}
+
+// cacheLatencyCollector measures the latency of the requests that the caches
+// receive.
+type cacheLatencyCollector struct {
+	tracers []*cacheLatencyTracer
+}
+
+func newCacheLatencyCollector(opts Options) Collector {
+	if !opts.ReportCacheLatency {
+		return nil
+	}
+
+	return &cacheLatencyCollector{}
+}
+
+func (c *cacheLatencyCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	for _, comp := range SelectComponents(s, r.Names.Cache, isCache) {
+		tracer := newHistogramTracer(
+			s.GetEngine(),
+			func(task tracing.Task) bool {
+				return task.Kind == "req_in"
+			})
+		c.tracers = append(c.tracers,
+			&cacheLatencyTracer{
+				tracer: tracer,
+				cache:  comp,
+			})
+		tracing.CollectTrace(comp, tracer)
+	}
+}
+
+func (c *cacheLatencyCollector) Report(r *Reporter) {
+	for _, tracer := range c.tracers {
+		histogram := tracer.tracer.histogram
+		if histogram.Mean() == 0 {
+			continue
+		}
+
+		r.emit(
+			Metric{
+				Location: tracer.cache.Name(),
+				What:     "req_average_latency",
+				Value:    float64(histogram.Mean()),
+				Unit:     "second",
+			},
+		)
+
+		histogram.Report(r, tracer.cache.Name(), "req_latency")
+	}
+}
+
+type cacheHitRateTracer struct {
+	tracer *tracing.StepCountTracer
+	cache  tracing.NamedHookable
+}
+
+// cacheHitRateCollector counts the hits, the misses and the MSHR hits of the
+// caches.
+type cacheHitRateCollector struct {
+	tracers []*cacheHitRateTracer
+}
+
+func newCacheHitRateCollector(opts Options) Collector {
+	if !opts.ReportCacheHitRate {
+		return nil
+	}
+
+	return &cacheHitRateCollector{}
+}
+
+func (c *cacheHitRateCollector) Inject(r *Reporter) {
+	for _, comp := range SelectComponents(r.simulation, r.Names.Cache, isCache) {
+		tracer := tracing.NewStepCountTracer(
+			func(task tracing.Task) bool { return true })
+		c.tracers = append(c.tracers,
+			&cacheHitRateTracer{
+				tracer: tracer,
+				cache:  comp,
+			})
+		tracing.CollectTrace(comp, tracer)
+	}
+}
+
+// Report reports the caches that received at least one request.
+func (c *cacheHitRateCollector) Report(r *Reporter) {
+	for _, tracer := range c.tracers {
+		totalTransaction := uint64(0)
+		for _, step := range cacheSteps {
+			totalTransaction += tracer.tracer.GetStepCount(step)
+		}
+
+		if totalTransaction == 0 {
+			continue
+		}
+
+		for _, step := range cacheSteps {
+			r.emit(Metric{
+				Location: tracer.cache.Name(),
+				What:     step,
+				Value:    float64(tracer.tracer.GetStepCount(step)),
+				Unit:     "count",
+			})
+		}
+	}
+}
+
+func (c *cacheHitRateCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		for _, step := range cacheSteps {
+			counters = append(counters, Metric{
+				Location: t.cache.Name(),
+				What:     step,
+				Value:    float64(t.tracer.GetStepCount(step)),
+				Unit:     "count",
+			})
+		}
+	}
+
+	return counters
+}
@@ -0,0 +1,96 @@
+package metrics_reporter
+
+import (
+	"log"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+)
+
+// A Collector collects a family of metrics. Inject selects the components of
+// the simulation and attaches the tracers to them, before the simulation
+// runs. Report adds the collected metrics to the reporter with AddMetric,
+// once the simulation completes.
+type Collector interface {
+	Inject(r *Reporter)
+	Report(r *Reporter)
+}
+
+// A CounterCollector is a collector whose metrics include counters that grow
+// during the simulation. The counters are sampled over time and split by
+// kernel launch.
+type CounterCollector interface {
+	Collector
+
+	// Counters returns the current values of the counters.
+	Counters() []Metric
+}
+
+// A CollectorFactory creates a collector from the options of a reporter. It
+// returns nil if the options do not enable the collector.
+type CollectorFactory func(opts Options) Collector
+
+type registeredCollector struct {
+	name    string
+	factory CollectorFactory
+}
+
+var collectorRegistry []registeredCollector
+
+// RegisterCollector adds a collector to every reporter whose options enable
+// it. The collectors report in the order in which they are registered, after
+// the built-in ones. Registering the same name twice panics.
+func RegisterCollector(name string, factory CollectorFactory) {
+	for _, c := range collectorRegistry {
+		if c.name == name {
+			log.Panicf("metrics collector %s is already registered", name)
+		}
+	}
+
+	collectorRegistry = append(collectorRegistry,
+		registeredCollector{name: name, factory: factory})
+}
+
+func init() {
+	RegisterCollector("kernelTime", newKernelTimeCollector)
+	RegisterCollector("instCount", newInstCountCollector)
+	RegisterCollector("cpiStack", newCPIStackCollector)
+	RegisterCollector("simdBusyTime", newSIMDBusyTimeCollector)
+	RegisterCollector("cacheLatency", newCacheLatencyCollector)
+	RegisterCollector("cacheHitRate", newCacheHitRateCollector)
+	RegisterCollector("tlbHitRate", newTLBHitRateCollector)
+	RegisterCollector("rdmaTransactionCount", newRDMACollector)
+	RegisterCollector("dramTransactionCount", newDRAMCollector)
+	RegisterCollector("portTraffic", newPortTrafficCollector)
+	RegisterCollector("rob", newROBCollector)
+	RegisterCollector("addressTranslator", newAddressTranslatorCollector)
+	RegisterCollector("mmu", newMMUCollector)
+	RegisterCollector("perKernel", newKernelCollector)
+}
+
+// AddCollector adds a collector to this reporter only. It must be called
+// before the tracers are injected.
+func (r *Reporter) AddCollector(c Collector) {
+	r.addedCollectors = append(r.addedCollectors, c)
+}
+
+// Simulation returns the simulation that the reporter collects metrics from.
+func (r *Reporter) Simulation() *simulation.Simulation {
+	return r.simulation
+}
+
+// InjectTime returns the time at which the tracers were injected, which is
+// the start of the time over which the rates and averages are computed.
+func (r *Reporter) InjectTime() sim.VTimeInSec {
+	return r.injectTime
+}
+
+func (r *Reporter) createCollectors() {
+	for _, c := range collectorRegistry {
+		if collector := c.factory(r.Options); collector != nil {
+			r.collectors = append(r.collectors, collector)
+		}
+	}
+
+	r.collectors = append(r.collectors, r.addedCollectors...)
+}
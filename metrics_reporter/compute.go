@@ -0,0 +1,312 @@
+package metrics_reporter
+
+import (
+	"sort"
+
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
+)
+
+type instCountTracer struct {
+	tracer *instTracer
+	cu     tracing.NamedHookable
+}
+
+// instCountCollector counts the instructions that the compute units complete.
+type instCountCollector struct {
+	tracers []*instCountTracer
+}
+
+func newInstCountCollector(opts Options) Collector {
+	if !opts.ReportInstCount {
+		return nil
+	}
+
+	return &instCountCollector{}
+}
+
+func (c *instCountCollector) Inject(r *Reporter) {
+	cus := SelectComponents(r.simulation, r.Names.ComputeUnit, isComputeUnit)
+	for _, comp := range cus {
+		tracer := newInstTracer()
+		c.tracers = append(c.tracers,
+			&instCountTracer{
+				tracer: tracer,
+				cu:     comp,
+			})
+		tracing.CollectTrace(comp, tracer)
+	}
+}
+
+func (c *instCountCollector) Report(r *Reporter) {
+	// The CPIs need the kernel time, so they are only reported if the kernel
+	// time is traced.
+	kernelTime := r.driverKernelTime()
+
+	for _, t := range c.tracers {
+		if cuComp, ok := t.cu.(*cu.ComputeUnit); ok {
+			cuFreq := float64(cuComp.Freq)
+			numCycle := kernelTime * cuFreq
+
+			r.emit(
+				Metric{
+					Location: t.cu.Name(),
+					What:     "cu_inst_count",
+					Value:    float64(t.tracer.count),
+					Unit:     "count",
+				},
+			)
+
+			if kernelTime > 0 && t.tracer.count > 0 {
+				r.emit(
+					Metric{
+						Location: t.cu.Name(),
+						What:     "cu_CPI",
+						Value:    numCycle / float64(t.tracer.count),
+						Unit:     "cycles/inst",
+					},
+				)
+			}
+
+			r.emit(
+				Metric{
+					Location: t.cu.Name(),
+					What:     "simd_inst_count",
+					Value:    float64(t.tracer.simdCount),
+					Unit:     "count",
+				},
+			)
+
+			if kernelTime > 0 && t.tracer.simdCount > 0 {
+				r.emit(
+					Metric{
+						Location: t.cu.Name(),
+						What:     "simd_CPI",
+						Value:    numCycle / float64(t.tracer.simdCount),
+						Unit:     "cycles/inst",
+					},
+				)
+			}
+		}
+	}
+}
+
+func (c *instCountCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters,
+			Metric{
+				Location: t.cu.Name(),
+				What:     "cu_inst_count",
+				Value:    float64(t.tracer.count),
+				Unit:     "count",
+			},
+			Metric{
+				Location: t.cu.Name(),
+				What:     "simd_inst_count",
+				Value:    float64(t.tracer.simdCount),
+				Unit:     "count",
+			},
+		)
+	}
+
+	return counters
+}
+
+type cuCPIStackTracer struct {
+	cu     tracing.NamedHookable
+	tracer *cu.CPIStackTracer
+}
+
+// cpiStackCollector splits the cycles per instruction of the compute units by
+// the reason that the instructions wait.
+type cpiStackCollector struct {
+	tracers []*cuCPIStackTracer
+}
+
+func newCPIStackCollector(opts Options) Collector {
+	if !opts.ReportCPIStack {
+		return nil
+	}
+
+	return &cpiStackCollector{}
+}
+
+func (c *cpiStackCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	for _, comp := range SelectComponents(s, r.Names.ComputeUnit, isComputeUnit) {
+		cuComp, ok := comp.(*cu.ComputeUnit)
+		if !ok {
+			continue
+		}
+
+		tracer := cu.NewCPIStackInstHook(cuComp, s.GetEngine())
+		tracing.CollectTrace(comp, tracer)
+
+		c.tracers = append(c.tracers,
+			&cuCPIStackTracer{
+				tracer: tracer,
+				cu:     comp,
+			})
+	}
+}
+
+func (c *cpiStackCollector) Report(r *Reporter) {
+	for _, t := range c.tracers {
+		cu := t.cu
+		hook := t.tracer
+
+		reportCPIStackEntries(r, hook, cu, false)
+		reportCPIStackEntries(r, hook, cu, true)
+	}
+}
+
+func reportCPIStackEntries(
+	r *Reporter,
+	hook *cu.CPIStackTracer,
+	cu tracing.NamedHookable,
+	simdStack bool,
+) {
+	cpiStack := hook.GetCPIStack()
+	if simdStack {
+		cpiStack = hook.GetSIMDCPIStack()
+	}
+
+	keys := make([]string, 0, len(cpiStack))
+	for k := range cpiStack {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	stackTypeName := "CPIStack"
+	if simdStack {
+		stackTypeName = "SIMDCPIStack"
+	}
+
+	for _, name := range keys {
+		value := cpiStack[name]
+		r.emit(
+			Metric{
+				Location: cu.Name(),
+				What:     stackTypeName + "." + name,
+				Value:    value,
+				Unit:     "cycles/inst",
+			},
+		)
+	}
+}
+
+type simdBusyTimeTracer struct {
+	tracer *tracing.BusyTimeTracer
+	simd   tracing.NamedHookable
+}
+
+// simdBusyTimeCollector measures the time that the SIMD units are busy.
+type simdBusyTimeCollector struct {
+	tracers []*simdBusyTimeTracer
+}
+
+func newSIMDBusyTimeCollector(opts Options) Collector {
+	if !opts.ReportSIMDBusyTime {
+		return nil
+	}
+
+	return &simdBusyTimeCollector{}
+}
+
+func (c *simdBusyTimeCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	cus := SelectComponents(s, r.Names.ComputeUnit, isComputeUnit)
+	for _, simd := range simdUnits(cus) {
+		perSIMDBusyTimeTracer := tracing.NewBusyTimeTracer(
+			s.GetEngine(),
+			func(task tracing.Task) bool {
+				return task.Kind == "pipeline"
+			})
+		c.tracers = append(c.tracers,
+			&simdBusyTimeTracer{
+				tracer: perSIMDBusyTimeTracer,
+				simd:   simd,
+			})
+		tracing.CollectTrace(simd, perSIMDBusyTimeTracer)
+	}
+}
+
+func (c *simdBusyTimeCollector) Report(r *Reporter) {
+	for _, m := range c.Counters() {
+		r.emit(m)
+	}
+}
+
+func (c *simdBusyTimeCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters, Metric{
+			Location: t.simd.Name(),
+			What:     "busy_time",
+			Value:    float64(t.tracer.BusyTime()),
+			Unit:     "second",
+		})
+	}
+
+	return counters
+}
+
+// instTracer can trace the number of instruction completed.
+type instTracer struct {
+	count     uint64
+	simdInst  bool
+	simdCount uint64
+	maxCount  uint64
+
+	inflightInst map[string]tracing.Task
+}
+
+// newInstTracer creates a tracer that can count the number of instructions.
+func newInstTracer() *instTracer {
+	t := &instTracer{
+		inflightInst: map[string]tracing.Task{},
+	}
+	return t
+}
+
+func (t *instTracer) StartTask(task tracing.Task) {
+	if task.Kind != "inst" {
+		return
+	}
+
+	if task.What == "VALU" {
+		t.simdInst = true
+	} else {
+		t.simdInst = false
+	}
+
+	t.inflightInst[task.ID] = task
+}
+
+func (t *instTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+func (t *instTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+func (t *instTracer) EndTask(task tracing.Task) {
+	_, found := t.inflightInst[task.ID]
+	if !found {
+		return
+	}
+
+	if t.simdInst {
+		t.simdCount++
+	}
+
+	delete(t.inflightInst, task.ID)
+
+	t.count++
+}
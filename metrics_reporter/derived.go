@@ -0,0 +1,314 @@
+package metrics_reporter
+
+import (
+	"regexp"
+)
+
+// GroupByLevel groups the locations whose names only differ by their indices,
+// such as all the GPU[*].SA[*].L1VCache[*] caches, into a single location.
+const GroupByLevel = "level"
+
+const derivedLocation = "simulation"
+
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// A Term names a metric that a formula adds up. A term without a location
+// refers to the metric at the location that the formula is evaluated for.
+// Otherwise, the metric is added up over all the locations that match the
+// location glob.
+type Term struct {
+	What     string `json:"what"`
+	Location string `json:"location"`
+}
+
+// A Formula derives a metric from the metrics that are already collected. The
+// value is the sum of the numerator terms divided by the sum of the
+// denominator terms, times the scale. An empty numerator or denominator is 1,
+// and a scale of 0 is 1.
+//
+// The formula is evaluated for every location that matches the location glob
+// and has all the terms without a location. If GroupBy is GroupByLevel, these
+// locations are grouped by level and the terms are added up over each group.
+// A formula whose terms all have a location is evaluated once, for the
+// "simulation" location.
+type Formula struct {
+	What        string  `json:"what"`
+	Unit        string  `json:"unit"`
+	Location    string  `json:"location"`
+	GroupBy     string  `json:"groupBy"`
+	Numerator   []Term  `json:"numerator"`
+	Denominator []Term  `json:"denominator"`
+	Scale       float64 `json:"scale"`
+}
+
+func terms(whats ...string) []Term {
+	list := make([]Term, 0, len(whats))
+	for _, what := range whats {
+		list = append(list, Term{What: what})
+	}
+
+	return list
+}
+
+// DefaultFormulas returns the formulas that derive the cache hit rates, the
+// misses per kilo-instruction of each cache level, the IPC of the compute
+// units, the TLB miss rates and the DRAM bandwidth utilization. The memory
+// controllers do not expose their peak bandwidth, so the bandwidth utilization
+// is only derived if the PeakDRAMBandwidth option is set.
+func DefaultFormulas() []Formula {
+	cacheAccesses := terms(
+		"read-hit", "read-miss", "read-mshr-hit",
+		"write-hit", "write-miss", "write-mshr-hit",
+	)
+
+	return []Formula{
+		{
+			What:        "hit_rate",
+			Unit:        "ratio",
+			Numerator:   terms("read-hit", "write-hit"),
+			Denominator: cacheAccesses,
+		},
+		{
+			What:        "mshr_hit_rate",
+			Unit:        "ratio",
+			Numerator:   terms("read-mshr-hit", "write-mshr-hit"),
+			Denominator: cacheAccesses,
+		},
+		{
+			What:        "mpki",
+			Unit:        "misses/kinst",
+			GroupBy:     GroupByLevel,
+			Numerator:   terms("read-miss", "write-miss"),
+			Denominator: []Term{{What: "cu_inst_count", Location: "*"}},
+			Scale:       1000,
+		},
+		{
+			What:        "ipc",
+			Unit:        "inst/cycle",
+			Denominator: terms("cu_CPI"),
+		},
+		{
+			What:        "miss_rate",
+			Unit:        "ratio",
+			Numerator:   terms("miss"),
+			Denominator: terms("hit", "miss", "mshr-hit"),
+		},
+		{
+			What:        "bandwidth_utilization",
+			Unit:        "ratio",
+			Numerator:   terms("bandwidth"),
+			Denominator: terms("peak_bandwidth"),
+		},
+	}
+}
+
+// AddFormula adds a formula that is evaluated after the default ones and the
+// ones of the options.
+func (r *Reporter) AddFormula(f Formula) {
+	r.Formulas = append(r.Formulas, f)
+}
+
+// metricIndex holds the collected metrics by location, in the order in which
+// the locations were first seen.
+type metricIndex struct {
+	locations []string
+	values    map[string]map[string]float64
+}
+
+func newMetricIndex(metrics []Metric) *metricIndex {
+	idx := &metricIndex{values: make(map[string]map[string]float64)}
+	for _, m := range metrics {
+		idx.add(m)
+	}
+
+	return idx
+}
+
+func (idx *metricIndex) add(m Metric) {
+	values, ok := idx.values[m.Location]
+	if !ok {
+		values = make(map[string]float64)
+		idx.values[m.Location] = values
+		idx.locations = append(idx.locations, m.Location)
+	}
+
+	values[m.What] = m.Value
+}
+
+// sum adds up a term over a group of locations. It returns false if the
+// metric is not found.
+func (idx *metricIndex) sum(t Term, group []string) (float64, bool) {
+	if t.Location != "" {
+		group = nil
+		for _, location := range idx.locations {
+			if matchesAny(location, []string{t.Location}) {
+				group = append(group, location)
+			}
+		}
+	}
+
+	total := 0.0
+	found := false
+
+	for _, location := range group {
+		if v, ok := idx.values[location][t.What]; ok {
+			total += v
+			found = true
+		}
+	}
+
+	return total, found
+}
+
+func (idx *metricIndex) sumAll(list []Term, group []string) (float64, bool) {
+	if len(list) == 0 {
+		return 1, true
+	}
+
+	total := 0.0
+	for _, t := range list {
+		v, ok := idx.sum(t, group)
+		if !ok {
+			return 0, false
+		}
+
+		total += v
+	}
+
+	return total, true
+}
+
+// groups returns the locations that a formula is evaluated for, under the
+// name of the location that the result is reported at.
+func (idx *metricIndex) groups(f Formula) (names []string, groups [][]string) {
+	var local []Term
+	for _, list := range [][]Term{f.Numerator, f.Denominator} {
+		for _, t := range list {
+			if t.Location == "" {
+				local = append(local, t)
+			}
+		}
+	}
+
+	if len(local) == 0 {
+		return []string{derivedLocation}, [][]string{nil}
+	}
+
+	byName := make(map[string]int)
+
+	for _, location := range idx.locations {
+		if f.Location != "" && !matchesAny(location, []string{f.Location}) {
+			continue
+		}
+
+		if !idx.hasAll(location, local) {
+			continue
+		}
+
+		name := location
+		if f.GroupBy == GroupByLevel {
+			name = indexPattern.ReplaceAllString(location, "[*]")
+		}
+
+		i, ok := byName[name]
+		if !ok {
+			i = len(names)
+			byName[name] = i
+			names = append(names, name)
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], location)
+	}
+
+	return names, groups
+}
+
+func (idx *metricIndex) hasAll(location string, list []Term) bool {
+	for _, t := range list {
+		if _, ok := idx.values[location][t.What]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reportDerivedMetrics evaluates the formulas over the metrics collected so
+// far. The formulas are evaluated in order, so a formula can use the results
+// of the previous ones. The metrics of each kernel are evaluated apart from
+// the others and from the metrics of the whole simulation, with the formulas
+// that only use counters.
+func (r *Reporter) reportDerivedMetrics() {
+	var formulas []Formula
+	if r.ReportDerivedMetrics {
+		formulas = append(formulas, DefaultFormulas()...)
+	}
+	formulas = append(formulas, r.Formulas...)
+
+	if len(formulas) == 0 {
+		return
+	}
+
+	var scopes []string
+	byScope := make(map[string][]Metric)
+
+	for _, m := range r.collected {
+		scope, location := splitScope(m.Location)
+		if _, ok := byScope[scope]; !ok {
+			scopes = append(scopes, scope)
+		}
+
+		m.Location = location
+		byScope[scope] = append(byScope[scope], m)
+	}
+
+	kernelFormulas := r.kernelFormulas(formulas)
+
+	for _, scope := range scopes {
+		scopeFormulas := formulas
+		if scope != "" {
+			scopeFormulas = kernelFormulas
+		}
+
+		r.evaluateFormulas(scopeFormulas, scope, newMetricIndex(byScope[scope]))
+	}
+}
+
+func (r *Reporter) evaluateFormulas(
+	formulas []Formula,
+	scope string,
+	idx *metricIndex,
+) {
+	for _, f := range formulas {
+		names, groups := idx.groups(f)
+
+		for i, name := range names {
+			numerator, ok := idx.sumAll(f.Numerator, groups[i])
+			if !ok {
+				continue
+			}
+
+			denominator, ok := idx.sumAll(f.Denominator, groups[i])
+			if !ok || denominator == 0 {
+				continue
+			}
+
+			scale := f.Scale
+			if scale == 0 {
+				scale = 1
+			}
+
+			m := Metric{
+				Location: name,
+				What:     f.What,
+				Value:    numerator / denominator * scale,
+				Unit:     f.Unit,
+			}
+			idx.add(m)
+
+			m.Location = scope + name
+			r.emit(m)
+		}
+	}
+}
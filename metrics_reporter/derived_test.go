@@ -0,0 +1,145 @@
+package metrics_reporter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func metric(location, what string, value float64) Metric {
+	return Metric{Location: location, What: what, Value: value}
+}
+
+func TestFormulas(t *testing.T) {
+	hitRate := Formula{
+		What:        "hit_rate",
+		Numerator:   terms("hit"),
+		Denominator: terms("hit", "miss"),
+	}
+
+	tests := []struct {
+		name     string
+		metrics  []Metric
+		formulas []Formula
+		want     []Metric
+	}{
+		{
+			name: "per location",
+			metrics: []Metric{
+				metric("L1[0]", "hit", 3),
+				metric("L1[0]", "miss", 1),
+				metric("L1[1]", "hit", 1),
+				metric("L1[1]", "miss", 1),
+			},
+			formulas: []Formula{hitRate},
+			want: []Metric{
+				metric("L1[0]", "hit_rate", 0.75),
+				metric("L1[1]", "hit_rate", 0.5),
+			},
+		},
+		{
+			name: "grouped by level",
+			metrics: []Metric{
+				metric("GPU[0].L1[0]", "hit", 3),
+				metric("GPU[0].L1[0]", "miss", 1),
+				metric("GPU[1].L1[1]", "hit", 1),
+				metric("GPU[1].L1[1]", "miss", 3),
+				metric("GPU[0].L2[0]", "hit", 1),
+				metric("GPU[0].L2[0]", "miss", 0),
+			},
+			formulas: []Formula{{
+				What:        "hit_rate",
+				GroupBy:     GroupByLevel,
+				Numerator:   terms("hit"),
+				Denominator: terms("hit", "miss"),
+			}},
+			want: []Metric{
+				metric("GPU[*].L1[*]", "hit_rate", 0.5),
+				metric("GPU[*].L2[*]", "hit_rate", 1),
+			},
+		},
+		{
+			name: "location glob",
+			metrics: []Metric{
+				metric("CU[0]", "inst", 1000),
+				metric("CU[1]", "inst", 3000),
+				metric("L1[0]", "miss", 2),
+				metric("L1[1]", "miss", 6),
+			},
+			formulas: []Formula{{
+				What:        "mpki",
+				Location:    "L1*",
+				Numerator:   terms("miss"),
+				Denominator: []Term{{What: "inst", Location: "CU*"}},
+				Scale:       1000,
+			}},
+			want: []Metric{
+				metric("L1[0]", "mpki", 0.5),
+				metric("L1[1]", "mpki", 1.5),
+			},
+		},
+		{
+			name: "all terms located",
+			metrics: []Metric{
+				metric("CU[0]", "inst", 1000),
+				metric("CU[1]", "inst", 3000),
+				metric("Driver", "cycles", 2000),
+			},
+			formulas: []Formula{{
+				What:        "ipc",
+				Numerator:   []Term{{What: "inst", Location: "CU*"}},
+				Denominator: []Term{{What: "cycles", Location: "Driver"}},
+			}},
+			want: []Metric{
+				metric(derivedLocation, "ipc", 2),
+			},
+		},
+		{
+			name: "missing term or zero denominator",
+			metrics: []Metric{
+				metric("L1[0]", "hit", 3),
+				metric("L1[1]", "hit", 0),
+				metric("L1[1]", "miss", 0),
+			},
+			formulas: []Formula{hitRate},
+		},
+		{
+			name: "chained formulas",
+			metrics: []Metric{
+				metric("L1[0]", "hit", 3),
+				metric("L1[0]", "miss", 1),
+			},
+			formulas: []Formula{
+				hitRate,
+				{
+					What:      "hit_percent",
+					Numerator: terms("hit_rate"),
+					Scale:     100,
+				},
+			},
+			want: []Metric{
+				metric("L1[0]", "hit_rate", 0.75),
+				metric("L1[0]", "hit_percent", 75),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &Reporter{Options: Options{Formulas: test.formulas}}
+			for _, m := range test.metrics {
+				r.emit(m)
+			}
+
+			r.reportDerivedMetrics()
+
+			got := r.collected[len(test.metrics):]
+			if len(got) == 0 && len(test.want) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package metrics_reporter
+
+import (
+	"log"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+type dramTransactionCountTracer struct {
+	tracer *dramTracer
+	dram   tracing.NamedHookable
+}
+
+// dramCollector counts the transactions of the memory controllers, and
+// measures their size and latency.
+type dramCollector struct {
+	tracers []*dramTransactionCountTracer
+}
+
+func newDRAMCollector(opts Options) Collector {
+	if !opts.ReportDRAMTransactionCount {
+		return nil
+	}
+
+	return &dramCollector{}
+}
+
+func (c *dramCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	memCtrls := SelectComponents(s, r.Names.MemController, isMemController)
+	for _, comp := range memCtrls {
+		t := &dramTransactionCountTracer{}
+		t.dram = comp
+		t.tracer = newDramTracer(s.GetEngine())
+
+		tracing.CollectTrace(t.dram, t.tracer)
+
+		c.tracers = append(c.tracers, t)
+	}
+}
+
+func (c *dramCollector) Report(r *Reporter) {
+	now := r.simulation.GetEngine().CurrentTime()
+	duration := float64(now - r.injectTime)
+
+	if len(c.tracers) > 0 && r.ReportDerivedMetrics && r.PeakDRAMBandwidth == 0 {
+		log.Printf("peak DRAM bandwidth is not set, " +
+			"the DRAM bandwidth utilization is not reported")
+	}
+
+	for _, t := range c.tracers {
+		r.emit(
+			Metric{
+				Location: t.dram.Name(),
+				What:     "read_trans_count",
+				Value:    float64(t.tracer.readCount),
+				Unit:     "count",
+			},
+		)
+		r.emit(
+			Metric{
+				Location: t.dram.Name(),
+				What:     "write_trans_count",
+				Value:    float64(t.tracer.writeCount),
+				Unit:     "count",
+			},
+		)
+		r.emit(
+			Metric{
+				Location: t.dram.Name(),
+				What:     "read_avg_latency",
+				Value:    float64(t.tracer.readLatency.Mean()),
+				Unit:     "second",
+			},
+		)
+		r.emit(
+			Metric{
+				Location: t.dram.Name(),
+				What:     "write_avg_latency",
+				Value:    float64(t.tracer.writeLatency.Mean()),
+				Unit:     "second",
+			},
+		)
+		r.emit(
+			Metric{
+				Location: t.dram.Name(),
+				What:     "read_size",
+				Value:    float64(t.tracer.readSize),
+				Unit:     "bytes",
+			},
+		)
+		r.emit(
+			Metric{
+				Location: t.dram.Name(),
+				What:     "write_size",
+				Value:    float64(t.tracer.writeSize),
+				Unit:     "bytes",
+			},
+		)
+
+		if duration > 0 {
+			r.emit(
+				Metric{
+					Location: t.dram.Name(),
+					What:     "bandwidth",
+					Value:    float64(t.tracer.readSize+t.tracer.writeSize) / duration,
+					Unit:     "B/s",
+				},
+			)
+		}
+
+		if r.PeakDRAMBandwidth > 0 {
+			r.emit(
+				Metric{
+					Location: t.dram.Name(),
+					What:     "peak_bandwidth",
+					Value:    r.PeakDRAMBandwidth,
+					Unit:     "B/s",
+				},
+			)
+		}
+
+		t.tracer.readLatency.Report(r, t.dram.Name(), "read_latency")
+		t.tracer.writeLatency.Report(r, t.dram.Name(), "write_latency")
+	}
+}
+
+func (c *dramCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters,
+			Metric{
+				Location: t.dram.Name(),
+				What:     "read_trans_count",
+				Value:    float64(t.tracer.readCount),
+				Unit:     "count",
+			},
+			Metric{
+				Location: t.dram.Name(),
+				What:     "write_trans_count",
+				Value:    float64(t.tracer.writeCount),
+				Unit:     "count",
+			},
+			Metric{
+				Location: t.dram.Name(),
+				What:     "read_size",
+				Value:    float64(t.tracer.readSize),
+				Unit:     "bytes",
+			},
+			Metric{
+				Location: t.dram.Name(),
+				What:     "write_size",
+				Value:    float64(t.tracer.writeSize),
+				Unit:     "bytes",
+			},
+		)
+	}
+
+	return counters
+}
+
+// dramTracer can trace DRAM activities.
+type dramTracer struct {
+	sync.Mutex
+	sim.TimeTeller
+
+	inflightTasks map[string]tracing.Task
+
+	readCount    int
+	writeCount   int
+	readSize     uint64
+	writeSize    uint64
+	readLatency  *LatencyHistogram
+	writeLatency *LatencyHistogram
+}
+
+func newDramTracer(timeTeller sim.TimeTeller) *dramTracer {
+	return &dramTracer{
+		TimeTeller:    timeTeller,
+		inflightTasks: make(map[string]tracing.Task),
+		readLatency:   NewLatencyHistogram(),
+		writeLatency:  NewLatencyHistogram(),
+	}
+}
+
+// StartTask records the task start time
+func (t *dramTracer) StartTask(task tracing.Task) {
+	t.Lock()
+	defer t.Unlock()
+
+	task.StartTime = t.TimeTeller.CurrentTime()
+
+	t.inflightTasks[task.ID] = task
+}
+
+// StepTask does nothing
+func (t *dramTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+// AddMilestone does nothing
+func (t *dramTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+// EndTask records the end of the task
+func (t *dramTracer) EndTask(task tracing.Task) {
+	t.Lock()
+	defer t.Unlock()
+
+	originalTask, ok := t.inflightTasks[task.ID]
+	if !ok {
+		return
+	}
+
+	task.EndTime = t.TimeTeller.CurrentTime()
+	taskTime := task.EndTime - originalTask.StartTime
+
+	switch originalTask.What {
+	case "*mem.ReadReq":
+		t.readCount++
+		t.readLatency.Add(taskTime)
+		t.readSize += originalTask.Detail.(*mem.ReadReq).AccessByteSize
+	case "*mem.WriteReq":
+		t.writeCount++
+		t.writeLatency.Add(taskTime)
+		t.writeSize += uint64(len(originalTask.Detail.(*mem.WriteReq).Data))
+	}
+
+	delete(t.inflightTasks, task.ID)
+}
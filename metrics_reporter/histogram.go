@@ -0,0 +1,252 @@
+package metrics_reporter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+const (
+	// The buckets of a histogram split each power of two nanoseconds into
+	// linear sub-buckets, so that a percentile is within 1/8 of the latency.
+	histogramUnit       = 1e-9
+	histogramSubBuckets = 8
+)
+
+// latencyPercentiles are the percentiles that are reported for each
+// histogram.
+var latencyPercentiles = []float64{50, 90, 99, 99.9}
+
+// A LatencyHistogram counts latencies in logarithmic buckets. The minimum and
+// the maximum are exact. It is used by the collectors of the reporter and by
+// the components that measure latencies themselves, so that all the latency
+// metrics share the same buckets and names.
+type LatencyHistogram struct {
+	count     uint64
+	sum       sim.VTimeInSec
+	min       sim.VTimeInSec
+	max       sim.VTimeInSec
+	zeroCount uint64
+	buckets   map[int]uint64
+}
+
+// NewLatencyHistogram creates an empty histogram.
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{buckets: make(map[int]uint64)}
+}
+
+// Add counts a latency.
+func (h *LatencyHistogram) Add(latency sim.VTimeInSec) {
+	if h.count == 0 || latency < h.min {
+		h.min = latency
+	}
+
+	if h.count == 0 || latency > h.max {
+		h.max = latency
+	}
+
+	h.count++
+	h.sum += latency
+
+	// The latency is rounded to the picosecond, as the difference of two
+	// times is often slightly below the bound of a bucket.
+	ns := math.Round(float64(latency)/histogramUnit*1000) / 1000
+	if ns <= 0 {
+		h.zeroCount++
+		return
+	}
+
+	h.buckets[bucketOf(ns)]++
+}
+
+// Count returns the number of latencies counted.
+func (h *LatencyHistogram) Count() uint64 {
+	return h.count
+}
+
+// Min returns the shortest latency.
+func (h *LatencyHistogram) Min() sim.VTimeInSec {
+	return h.min
+}
+
+// Max returns the longest latency.
+func (h *LatencyHistogram) Max() sim.VTimeInSec {
+	return h.max
+}
+
+// Mean returns the average latency.
+func (h *LatencyHistogram) Mean() sim.VTimeInSec {
+	if h.count == 0 {
+		return 0
+	}
+
+	return h.sum / sim.VTimeInSec(h.count)
+}
+
+// bucketOf returns the index of the bucket of a positive latency in
+// nanoseconds.
+func bucketOf(ns float64) int {
+	frac, exp := math.Frexp(ns)
+	sub := int((frac*2 - 1) * histogramSubBuckets)
+
+	return (exp-1)*histogramSubBuckets + sub
+}
+
+// bucketBounds returns the lowest latency of a bucket, which is inclusive,
+// and the highest, which is exclusive, in nanoseconds.
+func bucketBounds(i int) (low, high float64) {
+	octave := i / histogramSubBuckets
+	if i < 0 && i%histogramSubBuckets != 0 {
+		octave--
+	}
+
+	sub := i - octave*histogramSubBuckets
+	base := math.Ldexp(1, octave)
+	step := base / histogramSubBuckets
+
+	return base + float64(sub)*step, base + float64(sub+1)*step
+}
+
+func (h *LatencyHistogram) sortedBuckets() []int {
+	indices := make([]int, 0, len(h.buckets))
+	for i := range h.buckets {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	return indices
+}
+
+// Percentile returns the upper bound of the bucket that holds the latency
+// that p percent of the latencies do not exceed, bounded by the exact minimum
+// and maximum.
+func (h *LatencyHistogram) Percentile(p float64) sim.VTimeInSec {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(p / 100 * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	seen := h.zeroCount
+	if seen >= rank {
+		return h.min
+	}
+
+	for _, i := range h.sortedBuckets() {
+		seen += h.buckets[i]
+		if seen >= rank {
+			_, high := bucketBounds(i)
+			latency := sim.VTimeInSec(high * histogramUnit)
+
+			return min(max(latency, h.min), h.max)
+		}
+	}
+
+	return h.max
+}
+
+// A MetricAdder records metrics, as the Reporter does.
+type MetricAdder interface {
+	AddMetric(location, what string, value float64, unit string)
+}
+
+// Report adds the summary, the percentiles and the non-empty buckets of the
+// histogram to the metrics. The names of the buckets give their bounds in
+// nanoseconds.
+func (h *LatencyHistogram) Report(r MetricAdder, location, prefix string) {
+	if h.count == 0 {
+		return
+	}
+
+	r.AddMetric(location, prefix+"_min", float64(h.min), "second")
+	r.AddMetric(location, prefix+"_max", float64(h.max), "second")
+
+	for _, p := range latencyPercentiles {
+		name := fmt.Sprintf("%s_p%s", prefix, percentileName(p))
+		r.AddMetric(location, name, float64(h.Percentile(p)), "second")
+	}
+
+	if h.zeroCount > 0 {
+		r.AddMetric(location, prefix+"_bucket_0_0_ns", float64(h.zeroCount), "count")
+	}
+
+	for _, i := range h.sortedBuckets() {
+		low, high := bucketBounds(i)
+		name := fmt.Sprintf("%s_bucket_%g_%g_ns", prefix, low, high)
+		r.AddMetric(location, name, float64(h.buckets[i]), "count")
+	}
+}
+
+// percentileName writes 99.9 as 999, so that the names do not have dots.
+func percentileName(p float64) string {
+	if p == math.Trunc(p) {
+		return fmt.Sprintf("%.0f", p)
+	}
+
+	return fmt.Sprintf("%.0f", p*10)
+}
+
+// histogramTracer measures the latency of the tasks that pass a filter.
+type histogramTracer struct {
+	sync.Mutex
+	timeTeller sim.TimeTeller
+	filter     tracing.TaskFilter
+
+	inflightTasks map[string]sim.VTimeInSec
+	histogram     *LatencyHistogram
+}
+
+func newHistogramTracer(
+	timeTeller sim.TimeTeller,
+	filter tracing.TaskFilter,
+) *histogramTracer {
+	return &histogramTracer{
+		timeTeller:    timeTeller,
+		filter:        filter,
+		inflightTasks: make(map[string]sim.VTimeInSec),
+		histogram:     NewLatencyHistogram(),
+	}
+}
+
+// StartTask records the start time of the task.
+func (t *histogramTracer) StartTask(task tracing.Task) {
+	if !t.filter(task) {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.inflightTasks[task.ID] = t.timeTeller.CurrentTime()
+}
+
+// StepTask does nothing.
+func (t *histogramTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+// AddMilestone does nothing.
+func (t *histogramTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+// EndTask adds the latency of the task to the histogram.
+func (t *histogramTracer) EndTask(task tracing.Task) {
+	t.Lock()
+	defer t.Unlock()
+
+	startTime, ok := t.inflightTasks[task.ID]
+	if !ok {
+		return
+	}
+
+	t.histogram.Add(t.timeTeller.CurrentTime() - startTime)
+	delete(t.inflightTasks, task.ID)
+}
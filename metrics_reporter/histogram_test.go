@@ -0,0 +1,147 @@
+package metrics_reporter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+func nanoseconds(values ...float64) []sim.VTimeInSec {
+	latencies := make([]sim.VTimeInSec, 0, len(values))
+	for _, v := range values {
+		latencies = append(latencies, sim.VTimeInSec(v*1e-9))
+	}
+
+	return latencies
+}
+
+func TestLatencyHistogramPercentiles(t *testing.T) {
+	oneToHundred := make([]float64, 0, 100)
+	for i := 1; i <= 100; i++ {
+		oneToHundred = append(oneToHundred, float64(i))
+	}
+
+	tests := []struct {
+		name      string
+		latencies []sim.VTimeInSec
+		want      map[float64]float64
+	}{
+		{
+			name: "empty",
+			want: map[float64]float64{50: 0, 99: 0},
+		},
+		{
+			name:      "single latency",
+			latencies: nanoseconds(10),
+			want:      map[float64]float64{0: 10, 50: 10, 99.9: 10},
+		},
+		{
+			name:      "zero latencies",
+			latencies: nanoseconds(0, 0, 0, 10),
+			want:      map[float64]float64{50: 0, 75: 0, 99: 10},
+		},
+		{
+			// The percentiles are the upper bounds of the buckets: 1 ns is in
+			// [1, 1.125), 50 ns in [48, 52) and 90 ns in [88, 96). The bound
+			// of 99 ns, 104 ns, is above the exact maximum.
+			name:      "one to hundred",
+			latencies: nanoseconds(oneToHundred...),
+			want: map[float64]float64{
+				1: 1.125, 50: 52, 90: 96, 99: 100, 100: 100,
+			},
+		},
+		{
+			name:      "sub-nanosecond",
+			latencies: nanoseconds(0.25, 0.25, 0.5, 4),
+			want:      map[float64]float64{50: 0.28125, 75: 0.5625, 100: 4},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := NewLatencyHistogram()
+			for _, l := range test.latencies {
+				h.Add(l)
+			}
+
+			for p, wantNS := range test.want {
+				got := float64(h.Percentile(p)) / 1e-9
+				if math.Abs(got-wantNS) > 1e-6 {
+					t.Errorf("p%g: got %g ns, want %g ns", p, got, wantNS)
+				}
+			}
+		})
+	}
+}
+
+func TestLatencyHistogramPercentileError(t *testing.T) {
+	latencies := []float64{3, 7, 15, 33, 100, 257, 1000, 4097, 65000}
+
+	for _, ns := range latencies {
+		h := NewLatencyHistogram()
+		h.Add(sim.VTimeInSec(1e-9))
+		h.Add(sim.VTimeInSec(ns * 1e-9))
+		h.Add(sim.VTimeInSec(1e6 * 1e-9))
+
+		got := float64(h.Percentile(50)) / 1e-9
+		if got < ns || got > ns*(1+1.0/histogramSubBuckets) {
+			t.Errorf("%g ns: got p50 of %g ns", ns, got)
+		}
+	}
+}
+
+type metricList []Metric
+
+func (l *metricList) AddMetric(
+	location, what string,
+	value float64,
+	unit string,
+) {
+	*l = append(*l,
+		Metric{Location: location, What: what, Value: value, Unit: unit})
+}
+
+func TestLatencyHistogramReport(t *testing.T) {
+	h := NewLatencyHistogram()
+	for _, l := range nanoseconds(0, 1, 1.5, 50) {
+		h.Add(l)
+	}
+
+	var metrics metricList
+	h.Report(&metrics, "Cache", "read_latency")
+
+	want := map[string]float64{
+		"read_latency_min":                 0,
+		"read_latency_max":                 50e-9,
+		"read_latency_p50":                 1.125e-9,
+		"read_latency_p90":                 50e-9,
+		"read_latency_p99":                 50e-9,
+		"read_latency_p999":                50e-9,
+		"read_latency_bucket_0_0_ns":       1,
+		"read_latency_bucket_1_1.125_ns":   1,
+		"read_latency_bucket_1.5_1.625_ns": 1,
+		"read_latency_bucket_48_52_ns":     1,
+	}
+
+	if len(metrics) != len(want) {
+		t.Errorf("got %d metrics, want %d: %v",
+			len(metrics), len(want), metrics)
+	}
+
+	for _, m := range metrics {
+		if m.Location != "Cache" {
+			t.Errorf("%s: got location %s", m.What, m.Location)
+		}
+
+		v, ok := want[m.What]
+		if !ok {
+			t.Errorf("unexpected metric %s", m.What)
+			continue
+		}
+
+		if math.Abs(m.Value-v) > 1e-15 {
+			t.Errorf("%s: got %g, want %g", m.What, m.Value, v)
+		}
+	}
+}
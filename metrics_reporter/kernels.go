@@ -0,0 +1,258 @@
+package metrics_reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+const launchKernelCommandType = "*driver.LaunchKernelCommand"
+
+// kernelScopeSeparator separates the kernel from the location in the
+// locations of the per-kernel metrics, such as kernel[2]:fir/L1VCache. The
+// globs of the formulas do not match across it.
+const kernelScopeSeparator = "/"
+
+type kernelLaunch struct {
+	index    int
+	name     string
+	start    sim.VTimeInSec
+	counters map[counterKey]float64
+}
+
+func (l *kernelLaunch) scope() string {
+	return fmt.Sprintf("kernel[%d]:%s%s", l.index, l.name, kernelScopeSeparator)
+}
+
+// kernelTracer splits the counters by kernel launch. The counters are read
+// when the driver starts and completes a launch, so the launches that overlap
+// share the activity of the time that they overlap. The names of the kernels
+// are taken from the launch requests that the driver sends to the GPUs.
+type kernelTracer struct {
+	r      *Reporter
+	driver tracing.NamedHookable
+
+	launches    map[string]*kernelLaunch
+	numLaunches int
+	unnamed     *kernelLaunch
+	pendingName string
+	results     []Metric
+}
+
+// Func names the launch with the kernel of the first launch request that the
+// driver sends for it.
+func (t *kernelTracer) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosPortMsgSend {
+		return
+	}
+
+	req, ok := ctx.Item.(*protocol.LaunchKernelReq)
+	if !ok {
+		return
+	}
+
+	name := "kernel"
+	if req.CodeObject != nil && req.CodeObject.Symbol != nil {
+		name = req.CodeObject.Symbol.Name
+	}
+
+	if t.unnamed != nil {
+		t.unnamed.name = name
+		t.unnamed = nil
+
+		return
+	}
+
+	t.pendingName = name
+}
+
+// StartTask reads the counters at the start of a launch.
+func (t *kernelTracer) StartTask(task tracing.Task) {
+	if task.What != launchKernelCommandType {
+		return
+	}
+
+	launch := &kernelLaunch{
+		index:    t.numLaunches,
+		name:     t.pendingName,
+		start:    t.r.simulation.GetEngine().CurrentTime(),
+		counters: make(map[counterKey]float64),
+	}
+	t.numLaunches++
+
+	for _, c := range t.r.counters() {
+		launch.counters[counterKey{location: c.Location, what: c.What}] = c.Value
+	}
+
+	if launch.name == "" {
+		launch.name = "kernel"
+		t.unnamed = launch
+	}
+
+	t.pendingName = ""
+	t.launches[task.ID] = launch
+}
+
+// StepTask does nothing.
+func (t *kernelTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+// AddMilestone does nothing.
+func (t *kernelTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+// EndTask records how much the counters changed during the launch.
+func (t *kernelTracer) EndTask(task tracing.Task) {
+	launch, ok := t.launches[task.ID]
+	if !ok {
+		return
+	}
+
+	delete(t.launches, task.ID)
+
+	if t.unnamed == launch {
+		t.unnamed = nil
+	}
+
+	end := t.r.simulation.GetEngine().CurrentTime()
+	scope := launch.scope()
+
+	t.results = append(t.results,
+		Metric{
+			Location: scope + t.driver.Name(),
+			What:     "kernel_time",
+			Value:    float64(end - launch.start),
+			Unit:     "second",
+		},
+		Metric{
+			Location: scope + t.driver.Name(),
+			What:     "launch_start",
+			Value:    float64(launch.start),
+			Unit:     "second",
+		},
+	)
+
+	// The counters of a location are kept if one of them changed, so that
+	// the formulas find all the counters that they need.
+	var deltas []Metric
+	changed := make(map[string]bool)
+
+	for _, c := range t.r.counters() {
+		if c.Location == t.driver.Name() && c.What == "kernel_time" {
+			continue
+		}
+
+		c.Value -= launch.counters[counterKey{location: c.Location, what: c.What}]
+		if c.Value != 0 {
+			changed[c.Location] = true
+		}
+
+		deltas = append(deltas, c)
+	}
+
+	for _, c := range deltas {
+		if !changed[c.Location] {
+			continue
+		}
+
+		c.Location = scope + c.Location
+		t.results = append(t.results, c)
+	}
+}
+
+// kernelCollector breaks the counters of the other collectors down by kernel
+// launch. Only the collectors that implement CounterCollector are broken down;
+// the latencies, ratios and other metrics are only reported for the whole
+// simulation.
+type kernelCollector struct {
+	tracer *kernelTracer
+}
+
+func newKernelCollector(opts Options) Collector {
+	if !opts.ReportPerKernel {
+		return nil
+	}
+
+	return &kernelCollector{}
+}
+
+func (c *kernelCollector) Inject(r *Reporter) {
+	drivers := SelectComponents(r.simulation, nil, isDriver)
+	if len(drivers) == 0 {
+		return
+	}
+
+	c.tracer = &kernelTracer{
+		r:        r,
+		driver:   drivers[0],
+		launches: make(map[string]*kernelLaunch),
+	}
+	tracing.CollectTrace(drivers[0], c.tracer)
+
+	if owner, ok := drivers[0].(sim.Component); ok {
+		for _, port := range owner.Ports() {
+			port.AcceptHook(c.tracer)
+		}
+	}
+}
+
+func (c *kernelCollector) Report(r *Reporter) {
+	if c.tracer == nil {
+		return
+	}
+
+	for _, m := range c.tracer.results {
+		r.emit(m)
+	}
+}
+
+// kernelFormulas returns the formulas that are evaluated for each kernel. The
+// metrics of a kernel are the changes of the counters during its launch, so a
+// formula is only kept if its terms are counters, the kernel time, or the
+// results of the formulas kept before it. The other formulas would otherwise
+// mix the metrics of the kernel with the ones of the whole simulation.
+func (r *Reporter) kernelFormulas(formulas []Formula) []Formula {
+	known := map[string]bool{"kernel_time": true}
+	for _, c := range r.counters() {
+		known[c.What] = true
+	}
+
+	allKnown := func(list []Term) bool {
+		for _, t := range list {
+			if !known[t.What] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var kept []Formula
+
+	for _, f := range formulas {
+		if !allKnown(f.Numerator) || !allKnown(f.Denominator) {
+			continue
+		}
+
+		kept = append(kept, f)
+		known[f.What] = true
+	}
+
+	return kept
+}
+
+// splitScope splits the kernel scope, including the separator, from a
+// location. The scope is empty for the metrics of the whole simulation.
+func splitScope(location string) (scope, rest string) {
+	i := strings.Index(location, kernelScopeSeparator)
+	if i < 0 {
+		return "", location
+	}
+
+	return location[:i+len(kernelScopeSeparator)], location[i+len(kernelScopeSeparator):]
+}
@@ -0,0 +1,332 @@
+package metrics_reporter
+
+import (
+	"debug/elf"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+// fakeCounters is a counter collector whose counters are set by the tests.
+type fakeCounters struct {
+	values map[counterKey]float64
+	order  []counterKey
+}
+
+func newFakeCounters() *fakeCounters {
+	return &fakeCounters{values: make(map[counterKey]float64)}
+}
+
+func (c *fakeCounters) set(location, what string, value float64) {
+	key := counterKey{location: location, what: what}
+	if _, ok := c.values[key]; !ok {
+		c.order = append(c.order, key)
+	}
+
+	c.values[key] = value
+}
+
+func (c *fakeCounters) Inject(r *Reporter) {}
+
+func (c *fakeCounters) Report(r *Reporter) {}
+
+func (c *fakeCounters) Counters() []Metric {
+	counters := make([]Metric, 0, len(c.order))
+	for _, key := range c.order {
+		counters = append(counters, metric(key.location, key.what, c.values[key]))
+	}
+
+	return counters
+}
+
+type fakeDriver struct {
+	*sim.HookableBase
+}
+
+func (d *fakeDriver) Name() string {
+	return "Driver"
+}
+
+func newKernelTestTracer(t *testing.T, counters *fakeCounters) *kernelTracer {
+	s := simulation.MakeBuilder().
+		WithoutMonitoring().
+		WithOutputFileName(filepath.Join(t.TempDir(), "sim")).
+		Build()
+
+	r := &Reporter{simulation: s, collectors: []Collector{counters}}
+
+	return &kernelTracer{
+		r:        r,
+		driver:   &fakeDriver{HookableBase: sim.NewHookableBase()},
+		launches: make(map[string]*kernelLaunch),
+	}
+}
+
+func launchKernel(tracer *kernelTracer, name string) {
+	tracer.Func(sim.HookCtx{
+		Pos: sim.HookPosPortMsgSend,
+		Item: &protocol.LaunchKernelReq{
+			CodeObject: &insts.KernelCodeObject{
+				Symbol: &elf.Symbol{Name: name},
+			},
+		},
+	})
+}
+
+func launchTask(id string) tracing.Task {
+	return tracing.Task{ID: id, What: launchKernelCommandType}
+}
+
+// countersOf returns the results of a tracer other than the launch times.
+func countersOf(tracer *kernelTracer) []Metric {
+	var counters []Metric
+
+	for _, m := range tracer.results {
+		if m.What != "kernel_time" && m.What != "launch_start" {
+			counters = append(counters, m)
+		}
+	}
+
+	return counters
+}
+
+func TestSplitScope(t *testing.T) {
+	tests := []struct {
+		location  string
+		wantScope string
+		wantRest  string
+	}{
+		{"GPU[1].L2Cache[0]", "", "GPU[1].L2Cache[0]"},
+		{"kernel[2]:fir/GPU[1].L2Cache[0]", "kernel[2]:fir/", "GPU[1].L2Cache[0]"},
+		{"kernel[0]:kernel/Driver", "kernel[0]:kernel/", "Driver"},
+	}
+
+	for _, test := range tests {
+		scope, rest := splitScope(test.location)
+		if scope != test.wantScope || rest != test.wantRest {
+			t.Errorf("%s: got %q and %q, want %q and %q", test.location,
+				scope, rest, test.wantScope, test.wantRest)
+		}
+	}
+}
+
+func TestKernelTracerSplitsCounters(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]float64
+		after  map[string]float64
+		want   []Metric
+	}{
+		{
+			name:   "changed location keeps all its counters",
+			before: map[string]float64{"hit": 10, "miss": 2, "L2 hit": 5},
+			after:  map[string]float64{"hit": 30, "miss": 2, "L2 hit": 5},
+			want: []Metric{
+				metric("kernel[0]:fir/L1", "hit", 20),
+				metric("kernel[0]:fir/L1", "miss", 0),
+			},
+		},
+		{
+			name:   "unchanged counters",
+			before: map[string]float64{"hit": 10, "miss": 2, "L2 hit": 5},
+			after:  map[string]float64{"hit": 10, "miss": 2, "L2 hit": 5},
+		},
+		{
+			name:   "driver kernel time",
+			before: map[string]float64{"kernel_time": 1, "L2 hit": 5},
+			after:  map[string]float64{"kernel_time": 3, "L2 hit": 6},
+			want: []Metric{
+				metric("kernel[0]:fir/L2", "hit", 1),
+			},
+		},
+	}
+
+	locations := map[string]counterKey{
+		"hit":         {location: "L1", what: "hit"},
+		"miss":        {location: "L1", what: "miss"},
+		"L2 hit":      {location: "L2", what: "hit"},
+		"kernel_time": {location: "Driver", what: "kernel_time"},
+	}
+
+	setAll := func(c *fakeCounters, values map[string]float64) {
+		for _, name := range []string{"hit", "miss", "L2 hit", "kernel_time"} {
+			if v, ok := values[name]; ok {
+				key := locations[name]
+				c.set(key.location, key.what, v)
+			}
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counters := newFakeCounters()
+			tracer := newKernelTestTracer(t, counters)
+
+			setAll(counters, test.before)
+			launchKernel(tracer, "fir")
+			tracer.StartTask(launchTask("1"))
+
+			setAll(counters, test.after)
+			tracer.EndTask(launchTask("1"))
+
+			got := countersOf(tracer)
+			if len(got) == 0 && len(test.want) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestKernelTracerOverlappingLaunches(t *testing.T) {
+	counters := newFakeCounters()
+	tracer := newKernelTestTracer(t, counters)
+
+	counters.set("L1", "hit", 0)
+
+	// The first launch is named before it starts, and the second one once
+	// it has started.
+	launchKernel(tracer, "a")
+	tracer.StartTask(launchTask("1"))
+	counters.set("L1", "hit", 10)
+
+	tracer.StartTask(launchTask("2"))
+	launchKernel(tracer, "b")
+	counters.set("L1", "hit", 15)
+
+	tracer.EndTask(launchTask("1"))
+	counters.set("L1", "hit", 17)
+
+	tracer.EndTask(launchTask("2"))
+
+	want := []Metric{
+		metric("kernel[0]:a/L1", "hit", 15),
+		metric("kernel[1]:b/L1", "hit", 7),
+	}
+
+	if got := countersOf(tracer); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestKernelFormulas(t *testing.T) {
+	counters := newFakeCounters()
+	counters.set("L1", "hit", 0)
+	counters.set("L1", "miss", 0)
+	counters.set("CU", "inst", 0)
+
+	hitRate := Formula{
+		What:        "hit_rate",
+		Numerator:   terms("hit"),
+		Denominator: terms("hit", "miss"),
+	}
+	hitPercent := Formula{
+		What:      "hit_percent",
+		Numerator: terms("hit_rate"),
+		Scale:     100,
+	}
+	ipc := Formula{
+		What:        "ipc",
+		Denominator: terms("CPI"),
+	}
+	mpki := Formula{
+		What:        "mpki",
+		Numerator:   terms("miss"),
+		Denominator: []Term{{What: "inst", Location: "CU"}},
+		Scale:       1000,
+	}
+	perKernelTime := Formula{
+		What:        "inst_per_second",
+		Numerator:   []Term{{What: "inst", Location: "CU"}},
+		Denominator: []Term{{What: "kernel_time", Location: "Driver"}},
+	}
+
+	tests := []struct {
+		name     string
+		formulas []Formula
+		want     []string
+	}{
+		{
+			name:     "counter terms",
+			formulas: []Formula{hitRate, mpki, perKernelTime},
+			want:     []string{"hit_rate", "mpki", "inst_per_second"},
+		},
+		{
+			name:     "results of the kept formulas",
+			formulas: []Formula{hitRate, hitPercent},
+			want:     []string{"hit_rate", "hit_percent"},
+		},
+		{
+			name:     "other metrics",
+			formulas: []Formula{ipc, hitPercent, hitRate},
+			want:     []string{"hit_rate"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &Reporter{collectors: []Collector{counters}}
+
+			var got []string
+			for _, f := range r.kernelFormulas(test.formulas) {
+				got = append(got, f.What)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPerKernelDerivedMetrics(t *testing.T) {
+	counters := newFakeCounters()
+	counters.set("CU", "inst", 0)
+
+	r := &Reporter{
+		Options: Options{
+			Formulas: []Formula{{
+				What:        "ipc",
+				Denominator: terms("CPI"),
+			}, {
+				What:      "kinst",
+				Numerator: terms("inst"),
+				Scale:     1e-3,
+			}},
+		},
+		collectors: []Collector{counters},
+	}
+
+	inputs := []Metric{
+		metric("CU", "inst", 4000),
+		metric("CU", "CPI", 0.5),
+		metric("kernel[0]:fir/CU", "inst", 1000),
+		// A metric that is not a counter must not be mixed with the
+		// counters of the kernel.
+		metric("kernel[0]:fir/CU", "CPI", 0.5),
+	}
+	for _, m := range inputs {
+		r.emit(m)
+	}
+
+	r.reportDerivedMetrics()
+
+	want := []Metric{
+		metric("CU", "ipc", 2),
+		metric("CU", "kinst", 4),
+		metric("kernel[0]:fir/CU", "kinst", 1),
+	}
+
+	if got := r.collected[len(inputs):]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package metrics_reporter
+
+import (
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+type kernelTimeTracer struct {
+	tracer *tracing.BusyTimeTracer
+	comp   tracing.NamedHookable
+}
+
+// kernelTimeCollector measures the time that the driver runs kernels, and the
+// time that each command processor runs kernels.
+type kernelTimeCollector struct {
+	driver *kernelTimeTracer
+	perGPU []*kernelTimeTracer
+}
+
+func newKernelTimeCollector(opts Options) Collector {
+	if !opts.ReportKernelTime {
+		return nil
+	}
+
+	return &kernelTimeCollector{}
+}
+
+func (c *kernelTimeCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	// Driver tracer for kernel launch commands
+	drivers := SelectComponents(s, nil, isDriver)
+	if len(drivers) > 0 {
+		tracer := tracing.NewBusyTimeTracer(
+			s.GetEngine(),
+			func(task tracing.Task) bool {
+				return task.What == launchKernelCommandType
+			})
+		tracing.CollectTrace(drivers[0], tracer)
+		c.driver = &kernelTimeTracer{
+			tracer: tracer,
+			comp:   drivers[0],
+		}
+	}
+
+	// CommandProcessor tracers for kernel requests
+	cps := SelectComponents(s, r.Names.CommandProcessor, isCommandProcessor)
+	for _, comp := range cps {
+		tracer := tracing.NewBusyTimeTracer(
+			s.GetEngine(),
+			func(task tracing.Task) bool {
+				return task.What == "*protocol.LaunchKernelReq"
+			})
+		tracing.CollectTrace(comp, tracer)
+		c.perGPU = append(c.perGPU,
+			&kernelTimeTracer{
+				tracer: tracer,
+				comp:   comp,
+			})
+	}
+}
+
+func (c *kernelTimeCollector) Report(r *Reporter) {
+	for _, m := range c.Counters() {
+		r.emit(m)
+	}
+}
+
+func (c *kernelTimeCollector) Counters() []Metric {
+	var counters []Metric
+
+	if c.driver != nil {
+		counters = append(counters, Metric{
+			Location: c.driver.comp.Name(),
+			What:     "kernel_time",
+			Value:    float64(c.driver.tracer.BusyTime()),
+			Unit:     "second",
+		})
+	}
+
+	for _, t := range c.perGPU {
+		counters = append(counters, Metric{
+			Location: t.comp.Name(),
+			What:     "kernel_time",
+			Value:    float64(t.tracer.BusyTime()),
+			Unit:     "second",
+		})
+	}
+
+	return counters
+}
+
+// driverKernelTime returns the time that the driver ran kernels, or 0 if the
+// kernel time is not collected.
+func (r *Reporter) driverKernelTime() float64 {
+	for _, c := range r.collectors {
+		if k, ok := c.(*kernelTimeCollector); ok && k.driver != nil {
+			return float64(k.driver.tracer.BusyTime())
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,161 @@
+package metrics_reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// Options selects the metric families that a Reporter collects. Each family
+// is traced only if it is enabled when the tracers are injected.
+type Options struct {
+	ReportKernelTime           bool `json:"kernelTime"`
+	ReportInstCount            bool `json:"instCount"`
+	ReportCacheLatency         bool `json:"cacheLatency"`
+	ReportCacheHitRate         bool `json:"cacheHitRate"`
+	ReportTLBHitRate           bool `json:"tlbHitRate"`
+	ReportRDMATransactionCount bool `json:"rdmaTransactionCount"`
+	ReportDRAMTransactionCount bool `json:"dramTransactionCount"`
+	ReportSIMDBusyTime         bool `json:"simdBusyTime"`
+	ReportCPIStack             bool `json:"cpiStack"`
+	ReportPortTraffic          bool `json:"portTraffic"`
+	ReportROB                  bool `json:"rob"`
+	ReportAddressTranslator    bool `json:"addressTranslator"`
+	ReportMMU                  bool `json:"mmu"`
+	ReportDerivedMetrics       bool `json:"derivedMetrics"`
+
+	// ReportPerKernel breaks the counters down by kernel launch, and derives
+	// for each kernel only the formulas whose terms are counters, such as the
+	// misses per kilo-instruction but not the IPC, which uses the CPI of the
+	// whole simulation.
+	ReportPerKernel bool `json:"perKernel"`
+
+	// The counters are sampled every SampleInterval seconds, or every
+	// SampleCycles cycles of SampleFreq (1 GHz by default). Sampling is
+	// disabled if both are 0.
+	SampleInterval sim.VTimeInSec `json:"sampleInterval"`
+	SampleCycles   uint64         `json:"sampleCycles"`
+	SampleFreq     sim.Freq       `json:"sampleFreq"`
+
+	// Connections lists the connections whose traffic is added up from the
+	// traffic of their ports. A JSON topology lists them in its simulation
+	// section.
+	Connections []ConnectionOptions `json:"connections"`
+
+	// Formulas derives more metrics once the others are collected, after the
+	// default formulas if ReportDerivedMetrics is set. PeakDRAMBandwidth, in
+	// bytes per second, is the peak bandwidth of each memory controller that
+	// the bandwidth utilization is computed against. The memory controllers
+	// and the JSON topologies do not tell their peak bandwidth, so it must be
+	// set for the bandwidth utilization to be reported.
+	Formulas          []Formula `json:"formulas"`
+	PeakDRAMBandwidth float64   `json:"peakDRAMBandwidth"`
+
+	// Collectors holds the configuration of the collectors registered with
+	// RegisterCollector, by the name they are registered under. Each factory
+	// decodes its own section.
+	Collectors map[string]json.RawMessage `json:"collectors"`
+
+	Names ComponentNames `json:"names"`
+	Sinks []SinkOptions  `json:"sinks"`
+}
+
+// DefaultOptions returns the options used when nothing is configured. The
+// metrics are written to the data recorder. Only the kernel time is
+// collected, unless the METRICS_DIAGNOSTICS environment
+// variable enables the cache and TLB hit rates and the CPI stacks.
+func DefaultOptions() Options {
+	diagnostics := os.Getenv("METRICS_DIAGNOSTICS") == "1" ||
+		os.Getenv("METRICS_DIAGNOSTICS") == "true"
+
+	// The per-instruction tracers are expensive, so they are disabled by
+	// default.
+	return Options{
+		ReportKernelTime:           true,
+		ReportInstCount:            false,
+		ReportCacheLatency:         false,
+		ReportCacheHitRate:         diagnostics,
+		ReportTLBHitRate:           diagnostics,
+		ReportRDMATransactionCount: false,
+		ReportDRAMTransactionCount: false,
+		ReportSIMDBusyTime:         false,
+		ReportCPIStack:             diagnostics,
+		ReportPortTraffic:          false,
+		ReportROB:                  false,
+		ReportAddressTranslator:    false,
+		ReportMMU:                  false,
+		ReportDerivedMetrics:       false,
+		ReportPerKernel:            false,
+		Sinks:                      []SinkOptions{{Kind: SinkRecorder}},
+	}
+}
+
+// OptionsFromJSON reads the "metrics" section of a JSON topology. The
+// families that the section does not mention keep their default values. The
+// connections are taken from the simulation section, unless the metrics
+// section lists its own.
+func OptionsFromJSON(data []byte) (Options, error) {
+	topology := struct {
+		Metrics    *Options `json:"metrics"`
+		Simulation struct {
+			Connections []ConnectionOptions `json:"connections"`
+		} `json:"simulation"`
+	}{}
+
+	opts := DefaultOptions()
+	topology.Metrics = &opts
+
+	if err := json.Unmarshal(data, &topology); err != nil {
+		return Options{}, fmt.Errorf("cannot parse metrics options: %w", err)
+	}
+
+	if opts.Connections == nil {
+		opts.Connections = topology.Simulation.Connections
+	}
+
+	if err := opts.Validate(); err != nil {
+		return Options{}, fmt.Errorf("invalid metrics options: %w", err)
+	}
+
+	return opts, nil
+}
+
+// Validate checks the globs that select the components and the locations of
+// the formulas.
+func (o Options) Validate() error {
+	if err := o.Names.Validate(); err != nil {
+		return err
+	}
+
+	for _, f := range o.Formulas {
+		if err := validateGlobs(f.Location); err != nil {
+			return fmt.Errorf("formula %s: %w", f.What, err)
+		}
+
+		for _, t := range f.Numerator {
+			if err := validateGlobs(t.Location); err != nil {
+				return fmt.Errorf("formula %s: %w", f.What, err)
+			}
+		}
+
+		for _, t := range f.Denominator {
+			if err := validateGlobs(t.Location); err != nil {
+				return fmt.Errorf("formula %s: %w", f.What, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadOptions reads the metrics options from a JSON topology file.
+func LoadOptions(path string) (Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Options{}, fmt.Errorf("cannot read metrics options: %w", err)
+	}
+
+	return OptionsFromJSON(data)
+}
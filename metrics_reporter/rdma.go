@@ -0,0 +1,107 @@
+package metrics_reporter
+
+import (
+	"strings"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/rdma"
+)
+
+type rdmaTransactionCountTracer struct {
+	outgoingTracer *tracing.AverageTimeTracer
+	incomingTracer *tracing.AverageTimeTracer
+	rdmaEngine     *rdma.Comp
+}
+
+// rdmaCollector counts the transactions that the RDMA engines send to and
+// receive from other GPUs.
+type rdmaCollector struct {
+	tracers []*rdmaTransactionCountTracer
+}
+
+func newRDMACollector(opts Options) Collector {
+	if !opts.ReportRDMATransactionCount {
+		return nil
+	}
+
+	return &rdmaCollector{}
+}
+
+func (c *rdmaCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	for _, comp := range SelectComponents(s, r.Names.RDMA, isRDMA) {
+		rdmaComp, ok := comp.(*rdma.Comp)
+		if !ok {
+			continue
+		}
+
+		t := &rdmaTransactionCountTracer{}
+		t.rdmaEngine = rdmaComp
+		t.incomingTracer = tracing.NewAverageTimeTracer(
+			s.GetEngine(),
+			func(task tracing.Task) bool {
+				if task.Kind != "req_in" {
+					return false
+				}
+
+				isFromOutside := strings.Contains(
+					string(task.Detail.(sim.Msg).Meta().Src), "RDMA")
+				if !isFromOutside {
+					return false
+				}
+
+				return true
+			})
+		t.outgoingTracer = tracing.NewAverageTimeTracer(
+			s.GetEngine(),
+			func(task tracing.Task) bool {
+				if task.Kind != "req_in" {
+					return false
+				}
+
+				isFromOutside := strings.Contains(
+					string(task.Detail.(sim.Msg).Meta().Src), "RDMA")
+				if isFromOutside {
+					return false
+				}
+
+				return true
+			})
+
+		tracing.CollectTrace(t.rdmaEngine, t.incomingTracer)
+		tracing.CollectTrace(t.rdmaEngine, t.outgoingTracer)
+
+		c.tracers = append(c.tracers, t)
+	}
+}
+
+func (c *rdmaCollector) Report(r *Reporter) {
+	for _, m := range c.Counters() {
+		r.emit(m)
+	}
+}
+
+func (c *rdmaCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters,
+			Metric{
+				Location: t.rdmaEngine.Name(),
+				What:     "outgoing_trans_count",
+				Value:    float64(t.outgoingTracer.TotalCount()),
+				Unit:     "count",
+			},
+			Metric{
+				Location: t.rdmaEngine.Name(),
+				What:     "incoming_trans_count",
+				Value:    float64(t.incomingTracer.TotalCount()),
+				Unit:     "count",
+			},
+		)
+	}
+
+	return counters
+}
@@ -0,0 +1,174 @@
+package metrics_reporter
+
+import (
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+type robMetricsTracer struct {
+	rob    tracing.NamedHookable
+	tracer *robTracer
+}
+
+// robTracer follows the transactions of a reorder buffer. The responses are
+// returned in the order of the requests, so a response that arrives while the
+// oldest transaction still waits for its own is blocked by the head of the
+// buffer.
+type robTracer struct {
+	timeTeller sim.TimeTeller
+
+	transactions  []string
+	responded     map[string]bool
+	reqOutToReqIn map[string]string
+	count         uint64
+	occupancy     bufferOccupancy
+
+	blocked     bool
+	blockedTime sim.VTimeInSec
+	lastChange  sim.VTimeInSec
+}
+
+func newROBTracer(timeTeller sim.TimeTeller, start sim.VTimeInSec) *robTracer {
+	return &robTracer{
+		timeTeller:    timeTeller,
+		responded:     make(map[string]bool),
+		reqOutToReqIn: make(map[string]string),
+		occupancy:     bufferOccupancy{lastChange: start},
+		lastChange:    start,
+	}
+}
+
+// StartTask adds a transaction to the end of the buffer.
+func (t *robTracer) StartTask(task tracing.Task) {
+	now := t.timeTeller.CurrentTime()
+
+	switch task.Kind {
+	case "req_in":
+		t.update(now)
+		t.transactions = append(t.transactions, task.ID)
+		t.count++
+		t.occupancy.change(now, 1)
+		t.updateBlocking()
+	case "req_out":
+		t.reqOutToReqIn[task.ID] = task.ParentID
+	}
+}
+
+// StepTask does nothing.
+func (t *robTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+// AddMilestone does nothing.
+func (t *robTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+// EndTask marks a transaction as responded when the response of the bottom
+// arrives, and removes the transaction when it is returned to the top.
+func (t *robTracer) EndTask(task tracing.Task) {
+	now := t.timeTeller.CurrentTime()
+
+	if reqIn, ok := t.reqOutToReqIn[task.ID]; ok {
+		t.update(now)
+		delete(t.reqOutToReqIn, task.ID)
+		t.responded[reqIn] = true
+		t.updateBlocking()
+
+		return
+	}
+
+	for i, id := range t.transactions {
+		if id != task.ID {
+			continue
+		}
+
+		t.update(now)
+		t.transactions = append(t.transactions[:i], t.transactions[i+1:]...)
+		delete(t.responded, id)
+		t.occupancy.change(now, -1)
+		t.updateBlocking()
+
+		return
+	}
+}
+
+func (t *robTracer) update(now sim.VTimeInSec) {
+	if t.blocked {
+		t.blockedTime += now - t.lastChange
+	}
+
+	t.lastChange = now
+}
+
+func (t *robTracer) updateBlocking() {
+	t.blocked = len(t.transactions) > 0 &&
+		!t.responded[t.transactions[0]] &&
+		len(t.responded) > 0
+}
+
+// robCollector measures the occupancy of the reorder buffers.
+type robCollector struct {
+	tracers []*robMetricsTracer
+}
+
+func newROBCollector(opts Options) Collector {
+	if !opts.ReportROB {
+		return nil
+	}
+
+	return &robCollector{}
+}
+
+func (c *robCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	for _, comp := range SelectComponents(s, r.Names.ROB, isROB) {
+		tracer := newROBTracer(s.GetEngine(), r.injectTime)
+		c.tracers = append(c.tracers,
+			&robMetricsTracer{
+				rob:    comp,
+				tracer: tracer,
+			})
+		tracing.CollectTrace(comp, tracer)
+	}
+}
+
+func (c *robCollector) Report(r *Reporter) {
+	now := r.simulation.GetEngine().CurrentTime()
+	duration := float64(now - r.injectTime)
+
+	for _, t := range c.tracers {
+		tracer := t.tracer
+		if tracer.count == 0 {
+			continue
+		}
+
+		tracer.update(now)
+		tracer.occupancy.update(now)
+
+		name := t.rob.Name()
+		r.AddMetric(name, "trans_count", float64(tracer.count), "count")
+		r.AddMetric(name, "occupancy_avg",
+			tracer.occupancy.average(duration), "count")
+		r.AddMetric(name, "occupancy_max",
+			float64(tracer.occupancy.max), "count")
+		r.AddMetric(name, "hol_blocked_time",
+			float64(tracer.blockedTime), "second")
+	}
+}
+
+func (c *robCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters, Metric{
+			Location: t.rob.Name(),
+			What:     "trans_count",
+			Value:    float64(t.tracer.count),
+			Unit:     "count",
+		})
+	}
+
+	return counters
+}
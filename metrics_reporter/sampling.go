@@ -0,0 +1,143 @@
+package metrics_reporter
+
+import (
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+const (
+	timeSeriesTableName = "mgpusim_metrics_timeseries"
+)
+
+// A Sample is the value of a metric during a window of the simulation, such
+// as the change of a counter.
+type Sample struct {
+	Start    sim.VTimeInSec `json:"start"`
+	End      sim.VTimeInSec `json:"end"`
+	Location string         `json:"location"`
+	What     string         `json:"what"`
+	Value    float64        `json:"value"`
+	Unit     string         `json:"unit"`
+}
+
+type counterKey struct {
+	location string
+	what     string
+}
+
+// samplingHook takes the samples when the engine reaches the end of a
+// window. The samples are taken from the events of the simulation rather than
+// from events of their own, so that sampling neither keeps the engine running
+// nor extends the simulated time.
+type samplingHook struct {
+	r *Reporter
+}
+
+func (h *samplingHook) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosBeforeEvent {
+		return
+	}
+
+	evt, ok := ctx.Item.(sim.Event)
+	if !ok {
+		return
+	}
+
+	h.r.sampleUntil(evt.Time())
+}
+
+// samplePeriod returns the length of the sampling windows, or 0 if sampling
+// is disabled.
+func (r *Reporter) samplePeriod() sim.VTimeInSec {
+	if r.SampleInterval > 0 {
+		return r.SampleInterval
+	}
+
+	if r.SampleCycles == 0 {
+		return 0
+	}
+
+	freq := r.SampleFreq
+	if freq == 0 {
+		freq = 1 * sim.GHz
+	}
+
+	return sim.VTimeInSec(r.SampleCycles) * freq.Period()
+}
+
+func (r *Reporter) startSampling() {
+	r.period = r.samplePeriod()
+	if r.period <= 0 {
+		return
+	}
+
+	engine := r.simulation.GetEngine()
+	r.sampleOrigin = engine.CurrentTime()
+	r.sampleStart = r.sampleOrigin
+	r.lastCounters = make(map[counterKey]float64)
+
+	engine.AcceptHook(&samplingHook{r: r})
+}
+
+// sampleUntil closes all the windows that end before or at now. Nothing has
+// happened yet at now, so the windows do not include the events of now.
+func (r *Reporter) sampleUntil(now sim.VTimeInSec) {
+	for {
+		// The ends are computed from the origin so that rounding errors do not
+		// accumulate over the windows.
+		end := r.sampleOrigin + sim.VTimeInSec(r.numSamples+1)*r.period
+		if now < end {
+			return
+		}
+
+		r.takeSample(end)
+		r.numSamples++
+	}
+}
+
+// takeSample records the change of the counters since the previous sample.
+func (r *Reporter) takeSample(end sim.VTimeInSec) {
+	for _, c := range r.counters() {
+		key := counterKey{location: c.Location, what: c.What}
+		delta := c.Value - r.lastCounters[key]
+		r.lastCounters[key] = c.Value
+
+		r.emitSample(Sample{
+			Start:    r.sampleStart,
+			End:      end,
+			Location: c.Location,
+			What:     c.What,
+			Value:    delta,
+			Unit:     c.Unit,
+		})
+	}
+
+	r.sampleStart = end
+}
+
+// finishSampling records the last window, which ends early with the
+// simulation.
+func (r *Reporter) finishSampling() {
+	if r.period <= 0 {
+		return
+	}
+
+	now := r.simulation.GetEngine().CurrentTime()
+	r.sampleUntil(now)
+
+	if now > r.sampleStart {
+		r.takeSample(now)
+	}
+}
+
+// counters returns the current values of the counters of the collectors.
+func (r *Reporter) counters() []Metric {
+	var counters []Metric
+
+	for _, c := range r.collectors {
+		if cc, ok := c.(CounterCollector); ok {
+			counters = append(counters, cc.Counters()...)
+		}
+	}
+
+	return counters
+}
@@ -0,0 +1,213 @@
+package metrics_reporter
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/sarchlab/akita/v4/mem/cache/writearound"
+	"github.com/sarchlab/akita/v4/mem/cache/writeback"
+	"github.com/sarchlab/akita/v4/mem/cache/writethrough"
+	"github.com/sarchlab/akita/v4/mem/dram"
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/vm/addresstranslator"
+	"github.com/sarchlab/akita/v4/mem/vm/gmmu"
+	"github.com/sarchlab/akita/v4/mem/vm/mmu"
+	"github.com/sarchlab/akita/v4/mem/vm/tlb"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/rdma"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/rob"
+)
+
+// ComponentNames overrides the selection of the components that are traced.
+// By default, components are selected by their type. If a list of name globs
+// is given for a kind of component, the components whose names match one of
+// the globs are selected instead, whatever their type. The globs follow the
+// syntax of path.Match, so the brackets in names such as CU[0] must be
+// escaped.
+type ComponentNames struct {
+	CommandProcessor []string `json:"commandProcessor"`
+	ComputeUnit      []string `json:"computeUnit"`
+	Cache            []string `json:"cache"`
+	TLB              []string `json:"tlb"`
+	RDMA             []string `json:"rdma"`
+	MemController    []string `json:"memController"`
+
+	ROB               []string `json:"rob"`
+	AddressTranslator []string `json:"addressTranslator"`
+	MMU               []string `json:"mmu"`
+
+	// Port selects the ports whose traffic is traced by their full names,
+	// such as GPU[1].L2Cache[0].BottomPort. All the ports are traced if it is
+	// empty. The ports of the connections are traced in any case.
+	Port []string `json:"port"`
+}
+
+func isDriver(comp sim.Component) bool {
+	_, ok := comp.(*driver.Driver)
+	return ok
+}
+
+func isCommandProcessor(comp sim.Component) bool {
+	_, ok := comp.(*cp.CommandProcessor)
+	return ok
+}
+
+func isComputeUnit(comp sim.Component) bool {
+	_, ok := comp.(*cu.ComputeUnit)
+	return ok
+}
+
+func isCache(comp sim.Component) bool {
+	switch comp.(type) {
+	case *writeback.Comp, *writethrough.Comp, *writearound.Comp:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTLB(comp sim.Component) bool {
+	_, ok := comp.(*tlb.Comp)
+	return ok
+}
+
+func isRDMA(comp sim.Component) bool {
+	_, ok := comp.(*rdma.Comp)
+	return ok
+}
+
+func isROB(comp sim.Component) bool {
+	_, ok := comp.(*rob.ReorderBuffer)
+	return ok
+}
+
+func isAddressTranslator(comp sim.Component) bool {
+	_, ok := comp.(*addresstranslator.Comp)
+	return ok
+}
+
+func isMMU(comp sim.Component) bool {
+	switch comp.(type) {
+	case *mmu.Comp, *gmmu.GMMU:
+		return true
+	default:
+		return false
+	}
+}
+
+func isMemController(comp sim.Component) bool {
+	switch comp.(type) {
+	case *dram.Comp, *idealmemcontroller.Comp:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks that all the globs are well formed.
+func (n ComponentNames) Validate() error {
+	lists := []struct {
+		name  string
+		globs []string
+	}{
+		{"commandProcessor", n.CommandProcessor},
+		{"computeUnit", n.ComputeUnit},
+		{"cache", n.Cache},
+		{"tlb", n.TLB},
+		{"rdma", n.RDMA},
+		{"memController", n.MemController},
+		{"rob", n.ROB},
+		{"addressTranslator", n.AddressTranslator},
+		{"mmu", n.MMU},
+		{"port", n.Port},
+	}
+
+	for _, l := range lists {
+		if err := validateGlobs(l.globs...); err != nil {
+			return fmt.Errorf("names.%s: %w", l.name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateGlobs returns an error wrapping path.ErrBadPattern for the first
+// malformed glob.
+func validateGlobs(globs ...string) error {
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("glob %q: %w", glob, err)
+		}
+	}
+
+	return nil
+}
+
+// SelectComponents returns the hookable components that match the name globs,
+// or that satisfy the type predicate if there are no globs. The components
+// that cannot be hooked are skipped.
+func SelectComponents(
+	s *simulation.Simulation,
+	globs []string,
+	isKind func(sim.Component) bool,
+) []tracing.NamedHookable {
+	var selected []tracing.NamedHookable
+
+	for _, comp := range s.Components() {
+		if len(globs) > 0 {
+			if !matchesAny(comp.Name(), globs) {
+				continue
+			}
+		} else if !isKind(comp) {
+			continue
+		}
+
+		hookable, ok := comp.(tracing.NamedHookable)
+		if !ok {
+			continue
+		}
+
+		selected = append(selected, hookable)
+	}
+
+	return selected
+}
+
+// matchesAny tells if the name matches one of the globs. The globs are
+// validated with the options, so malformed globs simply do not match.
+func matchesAny(name string, globs []string) bool {
+	for _, glob := range globs {
+		if matched, err := path.Match(glob, name); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// simdUnits returns the SIMD units of the compute units. The SIMD units are
+// not registered in the simulation, so they are reached through their compute
+// unit.
+func simdUnits(cus []tracing.NamedHookable) []tracing.NamedHookable {
+	var units []tracing.NamedHookable
+
+	for _, comp := range cus {
+		cuComp, ok := comp.(*cu.ComputeUnit)
+		if !ok {
+			continue
+		}
+
+		for _, unit := range cuComp.SIMDUnit {
+			if hookable, ok := unit.(tracing.NamedHookable); ok {
+				units = append(units, hookable)
+			}
+		}
+	}
+
+	return units
+}
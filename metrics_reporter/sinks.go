@@ -0,0 +1,327 @@
+package metrics_reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sarchlab/akita/v4/datarecording"
+)
+
+// The kinds of sinks that can be selected in the options.
+const (
+	SinkRecorder  = "recorder"
+	SinkCSV       = "csv"
+	SinkJSONLines = "jsonl"
+	SinkTable     = "table"
+)
+
+const (
+	defaultCSVFile       = "metrics.csv"
+	defaultJSONLinesFile = "metrics.jsonl"
+)
+
+// A Metric is a value measured at a location of the simulation.
+type Metric struct {
+	Location string  `json:"location"`
+	What     string  `json:"what"`
+	Value    float64 `json:"value"`
+	Unit     string  `json:"unit"`
+}
+
+// A Sink receives the metrics and the samples that a Reporter collects.
+// Flush is called once, after the last metric is written.
+type Sink interface {
+	Write(m Metric) error
+	WriteSample(s Sample) error
+	Flush() error
+}
+
+// SinkOptions selects a sink. File is the file that a CSV or JSON-lines sink
+// writes to. A table sink prints to the standard output unless a file is
+// given.
+type SinkOptions struct {
+	Kind string `json:"kind"`
+	File string `json:"file"`
+}
+
+// NewSink creates the sink described by the options.
+func NewSink(opts SinkOptions, recorder datarecording.DataRecorder) (Sink, error) {
+	switch opts.Kind {
+	case SinkRecorder:
+		return NewRecorderSink(recorder), nil
+	case SinkCSV:
+		return NewCSVSink(fileOrDefault(opts.File, defaultCSVFile))
+	case SinkJSONLines:
+		return NewJSONLinesSink(fileOrDefault(opts.File, defaultJSONLinesFile))
+	case SinkTable:
+		if opts.File == "" {
+			return NewTableSink(os.Stdout), nil
+		}
+
+		f, err := os.Create(opts.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewTableSink(f), nil
+	default:
+		return nil, fmt.Errorf("unknown metrics sink %q", opts.Kind)
+	}
+}
+
+func fileOrDefault(file, defaultFile string) string {
+	if file == "" {
+		return defaultFile
+	}
+
+	return file
+}
+
+// RecorderSink writes the metrics to a table of the data recorder, and the
+// samples to another table.
+type RecorderSink struct {
+	recorder          datarecording.DataRecorder
+	timeSeriesCreated bool
+}
+
+// NewRecorderSink creates the metrics table in the data recorder.
+func NewRecorderSink(recorder datarecording.DataRecorder) *RecorderSink {
+	recorder.CreateTable(tableName, Metric{})
+
+	return &RecorderSink{recorder: recorder}
+}
+
+// Write inserts a metric in the table.
+func (s *RecorderSink) Write(m Metric) error {
+	s.recorder.InsertData(tableName, m)
+	return nil
+}
+
+// WriteSample inserts a sample in the time-series table.
+func (s *RecorderSink) WriteSample(sample Sample) error {
+	if !s.timeSeriesCreated {
+		s.recorder.CreateTable(timeSeriesTableName, Sample{})
+		s.timeSeriesCreated = true
+	}
+
+	s.recorder.InsertData(timeSeriesTableName, sample)
+
+	return nil
+}
+
+// Flush does nothing, as the data recorder is flushed when the simulation
+// terminates.
+func (s *RecorderSink) Flush() error {
+	return nil
+}
+
+// CSVSink writes one metric per line to a CSV file with a header. The
+// samples are written to another file, whose name ends with _timeseries.
+type CSVSink struct {
+	file   *os.File
+	writer *csv.Writer
+	path   string
+
+	samplesFile   *os.File
+	samplesWriter *csv.Writer
+}
+
+// NewCSVSink creates the file and writes the header.
+func NewCSVSink(path string) (*CSVSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &CSVSink{file: f, writer: csv.NewWriter(f), path: path}
+	if err := s.writer.Write([]string{"location", "what", "value", "unit"}); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write adds a line to the file.
+func (s *CSVSink) Write(m Metric) error {
+	return s.writer.Write([]string{
+		m.Location,
+		m.What,
+		strconv.FormatFloat(m.Value, 'g', -1, 64),
+		m.Unit,
+	})
+}
+
+// WriteSample adds a line to the time-series file, which is created with the
+// first sample.
+func (s *CSVSink) WriteSample(sample Sample) error {
+	if s.samplesWriter == nil {
+		f, err := os.Create(timeSeriesPath(s.path))
+		if err != nil {
+			return err
+		}
+
+		s.samplesFile = f
+		s.samplesWriter = csv.NewWriter(f)
+
+		err = s.samplesWriter.Write([]string{
+			"start", "end", "location", "what", "value", "unit"})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.samplesWriter.Write([]string{
+		strconv.FormatFloat(float64(sample.Start), 'g', -1, 64),
+		strconv.FormatFloat(float64(sample.End), 'g', -1, 64),
+		sample.Location,
+		sample.What,
+		strconv.FormatFloat(sample.Value, 'g', -1, 64),
+		sample.Unit,
+	})
+}
+
+// Flush writes the buffered lines and closes the files.
+func (s *CSVSink) Flush() error {
+	err := flushCSV(s.writer, s.file)
+
+	if s.samplesWriter != nil {
+		if samplesErr := flushCSV(s.samplesWriter, s.samplesFile); err == nil {
+			err = samplesErr
+		}
+	}
+
+	return err
+}
+
+func flushCSV(w *csv.Writer, f *os.File) error {
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// timeSeriesPath returns the path of the file that holds the samples, next to
+// the file that holds the metrics.
+func timeSeriesPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_timeseries" + ext
+}
+
+// JSONLinesSink writes one JSON object per metric and per line. The samples
+// are written to another file, whose name ends with _timeseries.
+type JSONLinesSink struct {
+	file    *os.File
+	encoder *json.Encoder
+	path    string
+
+	samplesFile    *os.File
+	samplesEncoder *json.Encoder
+}
+
+// NewJSONLinesSink creates the file.
+func NewJSONLinesSink(path string) (*JSONLinesSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLinesSink{file: f, encoder: json.NewEncoder(f), path: path}, nil
+}
+
+// Write adds a line to the file.
+func (s *JSONLinesSink) Write(m Metric) error {
+	return s.encoder.Encode(m)
+}
+
+// WriteSample adds a line to the time-series file, which is created with the
+// first sample.
+func (s *JSONLinesSink) WriteSample(sample Sample) error {
+	if s.samplesEncoder == nil {
+		f, err := os.Create(timeSeriesPath(s.path))
+		if err != nil {
+			return err
+		}
+
+		s.samplesFile = f
+		s.samplesEncoder = json.NewEncoder(f)
+	}
+
+	return s.samplesEncoder.Encode(sample)
+}
+
+// Flush closes the files.
+func (s *JSONLinesSink) Flush() error {
+	err := s.file.Close()
+
+	if s.samplesFile != nil {
+		if samplesErr := s.samplesFile.Close(); err == nil {
+			err = samplesErr
+		}
+	}
+
+	return err
+}
+
+// TableSink prints a summary of the metrics, grouped by location, once all the
+// metrics are collected.
+type TableSink struct {
+	out     io.Writer
+	metrics map[string][]Metric
+}
+
+// NewTableSink creates a sink that prints to a writer. The writer is closed
+// after printing if it is a file other than the standard output.
+func NewTableSink(out io.Writer) *TableSink {
+	return &TableSink{out: out, metrics: make(map[string][]Metric)}
+}
+
+// Write keeps the metric until the summary is printed.
+func (s *TableSink) Write(m Metric) error {
+	s.metrics[m.Location] = append(s.metrics[m.Location], m)
+	return nil
+}
+
+// WriteSample does nothing, as the summary only shows the final metrics.
+func (s *TableSink) WriteSample(sample Sample) error {
+	return nil
+}
+
+// Flush prints the locations in alphabetical order and their metrics in the
+// order that they were collected.
+func (s *TableSink) Flush() error {
+	locations := make([]string, 0, len(s.metrics))
+	for location := range s.metrics {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	for _, location := range locations {
+		fmt.Fprintf(w, "%s\n", location)
+		for _, m := range s.metrics[location] {
+			fmt.Fprintf(w, "  %s\t%.6g\t%s\n", m.What, m.Value, m.Unit)
+		}
+	}
+
+	err := w.Flush()
+
+	if f, ok := s.out.(*os.File); ok && f != os.Stdout {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,81 @@
+package metrics_reporter
+
+import (
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+var tlbSteps = []string{"hit", "miss", "mshr-hit"}
+
+type tlbHitRateTracer struct {
+	tracer *tracing.StepCountTracer
+	tlb    tracing.NamedHookable
+}
+
+// tlbHitRateCollector counts the hits, the misses and the MSHR hits of the
+// TLBs.
+type tlbHitRateCollector struct {
+	tracers []*tlbHitRateTracer
+}
+
+func newTLBHitRateCollector(opts Options) Collector {
+	if !opts.ReportTLBHitRate {
+		return nil
+	}
+
+	return &tlbHitRateCollector{}
+}
+
+func (c *tlbHitRateCollector) Inject(r *Reporter) {
+	for _, comp := range SelectComponents(r.simulation, r.Names.TLB, isTLB) {
+		tracer := tracing.NewStepCountTracer(
+			func(task tracing.Task) bool { return true })
+		c.tracers = append(c.tracers,
+			&tlbHitRateTracer{
+				tracer: tracer,
+				tlb:    comp,
+			})
+		tracing.CollectTrace(comp, tracer)
+	}
+}
+
+// Report reports the TLBs that received at least one request.
+func (c *tlbHitRateCollector) Report(r *Reporter) {
+	for _, tracer := range c.tracers {
+		totalTransaction := uint64(0)
+		for _, step := range tlbSteps {
+			totalTransaction += tracer.tracer.GetStepCount(step)
+		}
+
+		if totalTransaction == 0 {
+			continue
+		}
+
+		for _, step := range tlbSteps {
+			r.emit(
+				Metric{
+					Location: tracer.tlb.Name(),
+					What:     step,
+					Value:    float64(tracer.tracer.GetStepCount(step)),
+					Unit:     "count",
+				},
+			)
+		}
+	}
+}
+
+func (c *tlbHitRateCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		for _, step := range tlbSteps {
+			counters = append(counters, Metric{
+				Location: t.tlb.Name(),
+				What:     step,
+				Value:    float64(t.tracer.GetStepCount(step)),
+				Unit:     "count",
+			})
+		}
+	}
+
+	return counters
+}
@@ -0,0 +1,305 @@
+package metrics_reporter
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+)
+
+// ConnectionOptions names a connection and the ports plugged into it, with
+// the same fields as the connections of a JSON topology. The connections
+// cannot be found from the simulation, so their traffic is only reported if
+// they are listed.
+type ConnectionOptions struct {
+	Name  string        `json:"name"`
+	Plugs []PlugOptions `json:"plugs"`
+}
+
+// PlugOptions is a port of a component, named as in the component.
+type PlugOptions struct {
+	Component string `json:"component"`
+	Port      string `json:"port"`
+}
+
+// bufferOccupancy integrates the number of messages in a buffer over time.
+type bufferOccupancy struct {
+	size         int
+	max          int
+	area         float64
+	nonEmptyTime sim.VTimeInSec
+	lastChange   sim.VTimeInSec
+}
+
+func (b *bufferOccupancy) update(now sim.VTimeInSec) {
+	duration := now - b.lastChange
+	b.area += float64(b.size) * float64(duration)
+	if b.size > 0 {
+		b.nonEmptyTime += duration
+	}
+
+	b.lastChange = now
+}
+
+// average returns the average number of messages over a duration that ends
+// with the last update.
+func (b *bufferOccupancy) average(duration float64) float64 {
+	if duration <= 0 {
+		return 0
+	}
+
+	return b.area / duration
+}
+
+func (b *bufferOccupancy) change(now sim.VTimeInSec, delta int) {
+	b.update(now)
+
+	b.size += delta
+	b.max = max(b.max, b.size)
+}
+
+// portTracer counts the messages that go through a port and the occupancy of
+// its buffers. A connection delivers a message in the cycle that it is sent
+// unless the buffer of the destination is full, so the time during which the
+// outgoing buffer holds messages is the time that the port is blocked by
+// backpressure.
+type portTracer struct {
+	port       sim.Port
+	timeTeller sim.TimeTeller
+
+	outMsgs  uint64
+	outBytes uint64
+	inMsgs   uint64
+	inBytes  uint64
+	in       bufferOccupancy
+	out      bufferOccupancy
+}
+
+func (t *portTracer) Func(ctx sim.HookCtx) {
+	now := t.timeTeller.CurrentTime()
+
+	switch ctx.Pos {
+	case sim.HookPosPortMsgSend:
+		t.outMsgs++
+		t.outBytes += uint64(ctx.Item.(sim.Msg).Meta().TrafficBytes)
+		t.out.change(now, 1)
+	case sim.HookPosPortMsgRetrieveOutgoing:
+		t.out.change(now, -1)
+	case sim.HookPosPortMsgRecvd:
+		t.inMsgs++
+		t.inBytes += uint64(ctx.Item.(sim.Msg).Meta().TrafficBytes)
+		t.in.change(now, 1)
+	case sim.HookPosPortMsgRetrieveIncoming:
+		t.in.change(now, -1)
+	}
+}
+
+type connectionTracer struct {
+	name  string
+	ports []*portTracer
+}
+
+// portTrafficCollector measures the traffic of the ports and the connections.
+type portTrafficCollector struct {
+	ports       []*portTracer
+	connections []*connectionTracer
+}
+
+func newPortTrafficCollector(opts Options) Collector {
+	if !opts.ReportPortTraffic {
+		return nil
+	}
+
+	return &portTrafficCollector{}
+}
+
+func (pc *portTrafficCollector) Inject(r *Reporter) {
+	s := r.simulation
+	tracers := make(map[sim.Port]*portTracer)
+
+	for _, comp := range s.Components() {
+		for _, port := range comp.Ports() {
+			if len(r.Names.Port) > 0 && !matchesAny(port.Name(), r.Names.Port) {
+				continue
+			}
+
+			pc.tracePort(r, tracers, port)
+		}
+	}
+
+	// The ports of the connections are traced even if the port globs do not
+	// select them, so that the connections add up all their ports.
+	for _, conn := range r.Connections {
+		c := &connectionTracer{name: conn.Name}
+
+		for _, plug := range conn.Plugs {
+			port, err := findPort(s, plug)
+			if err != nil {
+				log.Printf("connection %s: %v", conn.Name, err)
+				continue
+			}
+
+			c.ports = append(c.ports, pc.tracePort(r, tracers, port))
+		}
+
+		pc.connections = append(pc.connections, c)
+	}
+}
+
+// tracePort returns the tracer of a port, attaching a new one if the port is
+// not traced yet.
+func (pc *portTrafficCollector) tracePort(
+	r *Reporter,
+	tracers map[sim.Port]*portTracer,
+	port sim.Port,
+) *portTracer {
+	if t, ok := tracers[port]; ok {
+		return t
+	}
+
+	t := &portTracer{
+		port:       port,
+		timeTeller: r.simulation.GetEngine(),
+		in:         bufferOccupancy{lastChange: r.injectTime},
+		out:        bufferOccupancy{lastChange: r.injectTime},
+	}
+	port.AcceptHook(t)
+
+	tracers[port] = t
+	pc.ports = append(pc.ports, t)
+
+	return t
+}
+
+// findPort returns the port that a plug of a connection refers to.
+func findPort(s *simulation.Simulation, plug PlugOptions) (sim.Port, error) {
+	for _, comp := range s.Components() {
+		if comp.Name() == plug.Component {
+			return portByName(comp, plug.Port)
+		}
+	}
+
+	return nil, fmt.Errorf("component %s not found", plug.Component)
+}
+
+// portByName returns a port of a component by the name that the component
+// gives it. GetPortByName panics if the component has no such port, so the
+// panic is turned into an error.
+func portByName(comp sim.Component, name string) (port sim.Port, err error) {
+	defer func() {
+		if recover() != nil {
+			port = nil
+			err = fmt.Errorf("component %s has no port %s", comp.Name(), name)
+		}
+	}()
+
+	return comp.GetPortByName(name), nil
+}
+
+// validateConnections checks that the plugs of the connections refer to
+// existing ports.
+func validateConnections(
+	s *simulation.Simulation,
+	connections []ConnectionOptions,
+) error {
+	for _, conn := range connections {
+		for _, plug := range conn.Plugs {
+			if _, err := findPort(s, plug); err != nil {
+				return fmt.Errorf("connection %s: %w", conn.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (pc *portTrafficCollector) Report(r *Reporter) {
+	now := r.simulation.GetEngine().CurrentTime()
+	duration := float64(now - r.injectTime)
+
+	bandwidth := func(bytes uint64) float64 {
+		if duration <= 0 {
+			return 0
+		}
+
+		return float64(bytes) / duration
+	}
+
+	for _, t := range pc.ports {
+		if t.outMsgs == 0 && t.inMsgs == 0 {
+			continue
+		}
+
+		t.in.update(now)
+		t.out.update(now)
+
+		name := t.port.Name()
+		r.AddMetric(name, "out_msgs", float64(t.outMsgs), "count")
+		r.AddMetric(name, "out_bytes", float64(t.outBytes), "bytes")
+		r.AddMetric(name, "out_bandwidth", bandwidth(t.outBytes), "B/s")
+		r.AddMetric(name, "in_msgs", float64(t.inMsgs), "count")
+		r.AddMetric(name, "in_bytes", float64(t.inBytes), "bytes")
+		r.AddMetric(name, "in_bandwidth", bandwidth(t.inBytes), "B/s")
+
+		r.AddMetric(name, "in_buffer_avg", t.in.average(duration), "count")
+		r.AddMetric(name, "out_buffer_avg", t.out.average(duration), "count")
+		r.AddMetric(name, "in_buffer_max", float64(t.in.max), "count")
+		r.AddMetric(name, "out_buffer_max", float64(t.out.max), "count")
+		r.AddMetric(name, "blocked_time", float64(t.out.nonEmptyTime), "second")
+	}
+
+	for _, c := range pc.connections {
+		var msgs, bytes uint64
+		var blocked sim.VTimeInSec
+		maxBuffer := 0
+
+		for _, t := range c.ports {
+			msgs += t.outMsgs
+			bytes += t.outBytes
+			blocked += t.out.nonEmptyTime
+			maxBuffer = max(maxBuffer, t.in.max)
+		}
+
+		r.AddMetric(c.name, "msgs", float64(msgs), "count")
+		r.AddMetric(c.name, "bytes", float64(bytes), "bytes")
+		r.AddMetric(c.name, "bandwidth", bandwidth(bytes), "B/s")
+		r.AddMetric(c.name, "blocked_time", float64(blocked), "second")
+		r.AddMetric(c.name, "max_buffer", float64(maxBuffer), "count")
+	}
+}
+
+func (pc *portTrafficCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range pc.ports {
+		counters = append(counters,
+			Metric{
+				Location: t.port.Name(),
+				What:     "out_msgs",
+				Value:    float64(t.outMsgs),
+				Unit:     "count",
+			},
+			Metric{
+				Location: t.port.Name(),
+				What:     "out_bytes",
+				Value:    float64(t.outBytes),
+				Unit:     "bytes",
+			},
+			Metric{
+				Location: t.port.Name(),
+				What:     "in_msgs",
+				Value:    float64(t.inMsgs),
+				Unit:     "count",
+			},
+			Metric{
+				Location: t.port.Name(),
+				What:     "in_bytes",
+				Value:    float64(t.inBytes),
+				Unit:     "bytes",
+			},
+		)
+	}
+
+	return counters
+}
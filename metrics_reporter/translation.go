@@ -0,0 +1,315 @@
+package metrics_reporter
+
+import (
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+)
+
+const translationReqType = "*vm.TranslationReq"
+
+type addressTranslatorTracer struct {
+	translator tracing.NamedHookable
+	tracer     *translationTracer
+}
+
+// translationTracer measures the translations that an address translator
+// requests from its TLB.
+type translationTracer struct {
+	timeTeller sim.TimeTeller
+
+	inflight  map[string]sim.VTimeInSec
+	occupancy bufferOccupancy
+	latency   *LatencyHistogram
+}
+
+func newTranslationTracer(
+	timeTeller sim.TimeTeller,
+	start sim.VTimeInSec,
+) *translationTracer {
+	return &translationTracer{
+		timeTeller: timeTeller,
+		inflight:   make(map[string]sim.VTimeInSec),
+		occupancy:  bufferOccupancy{lastChange: start},
+		latency:    NewLatencyHistogram(),
+	}
+}
+
+// StartTask records the start of a translation.
+func (t *translationTracer) StartTask(task tracing.Task) {
+	if task.Kind != "req_out" || task.What != translationReqType {
+		return
+	}
+
+	now := t.timeTeller.CurrentTime()
+	t.inflight[task.ID] = now
+	t.occupancy.change(now, 1)
+}
+
+// StepTask does nothing.
+func (t *translationTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+// AddMilestone does nothing.
+func (t *translationTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+// EndTask adds the latency of the translation to the histogram.
+func (t *translationTracer) EndTask(task tracing.Task) {
+	startTime, ok := t.inflight[task.ID]
+	if !ok {
+		return
+	}
+
+	now := t.timeTeller.CurrentTime()
+	t.latency.Add(now - startTime)
+	t.occupancy.change(now, -1)
+	delete(t.inflight, task.ID)
+}
+
+type mmuTracer struct {
+	mmu    tracing.NamedHookable
+	tracer *pageWalkTracer
+}
+
+// pageWalkTracer measures the page walks of an MMU, and the time that the
+// translation requests wait in the incoming buffer before a walk starts. The
+// arrivals are observed by hooking the ports of the MMU.
+type pageWalkTracer struct {
+	timeTeller sim.TimeTeller
+
+	arrivals     map[string]sim.VTimeInSec
+	walks        map[string]sim.VTimeInSec
+	queue        bufferOccupancy
+	walking      bufferOccupancy
+	queueLatency *LatencyHistogram
+	walkLatency  *LatencyHistogram
+}
+
+func newPageWalkTracer(
+	timeTeller sim.TimeTeller,
+	start sim.VTimeInSec,
+) *pageWalkTracer {
+	return &pageWalkTracer{
+		timeTeller:   timeTeller,
+		arrivals:     make(map[string]sim.VTimeInSec),
+		walks:        make(map[string]sim.VTimeInSec),
+		queue:        bufferOccupancy{lastChange: start},
+		walking:      bufferOccupancy{lastChange: start},
+		queueLatency: NewLatencyHistogram(),
+		walkLatency:  NewLatencyHistogram(),
+	}
+}
+
+// Func records the arrival of the translation requests.
+func (t *pageWalkTracer) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosPortMsgRecvd {
+		return
+	}
+
+	req, ok := ctx.Item.(*vm.TranslationReq)
+	if !ok {
+		return
+	}
+
+	now := t.timeTeller.CurrentTime()
+	t.arrivals[req.ID] = now
+	t.queue.change(now, 1)
+}
+
+// StartTask records the start of a page walk.
+func (t *pageWalkTracer) StartTask(task tracing.Task) {
+	if task.Kind != "req_in" || task.What != translationReqType {
+		return
+	}
+
+	now := t.timeTeller.CurrentTime()
+	t.walks[task.ID] = now
+	t.walking.change(now, 1)
+
+	msg, ok := task.Detail.(sim.Msg)
+	if !ok {
+		return
+	}
+
+	if arrival, ok := t.arrivals[msg.Meta().ID]; ok {
+		t.queueLatency.Add(now - arrival)
+		t.queue.change(now, -1)
+		delete(t.arrivals, msg.Meta().ID)
+	}
+}
+
+// StepTask does nothing.
+func (t *pageWalkTracer) StepTask(task tracing.Task) {
+	// Do nothing
+}
+
+// AddMilestone does nothing.
+func (t *pageWalkTracer) AddMilestone(milestone tracing.Milestone) {
+	// Do nothing
+}
+
+// EndTask adds the latency of the page walk to the histogram.
+func (t *pageWalkTracer) EndTask(task tracing.Task) {
+	startTime, ok := t.walks[task.ID]
+	if !ok {
+		return
+	}
+
+	now := t.timeTeller.CurrentTime()
+	t.walkLatency.Add(now - startTime)
+	t.walking.change(now, -1)
+	delete(t.walks, task.ID)
+}
+
+// addressTranslatorCollector measures the translations of the address
+// translators.
+type addressTranslatorCollector struct {
+	tracers []*addressTranslatorTracer
+}
+
+func newAddressTranslatorCollector(opts Options) Collector {
+	if !opts.ReportAddressTranslator {
+		return nil
+	}
+
+	return &addressTranslatorCollector{}
+}
+
+func (c *addressTranslatorCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	for _, comp := range SelectComponents(
+		s, r.Names.AddressTranslator, isAddressTranslator) {
+		tracer := newTranslationTracer(s.GetEngine(), r.injectTime)
+		c.tracers = append(c.tracers,
+			&addressTranslatorTracer{
+				translator: comp,
+				tracer:     tracer,
+			})
+		tracing.CollectTrace(comp, tracer)
+	}
+}
+
+func (c *addressTranslatorCollector) Report(r *Reporter) {
+	now := r.simulation.GetEngine().CurrentTime()
+	duration := float64(now - r.injectTime)
+
+	for _, t := range c.tracers {
+		tracer := t.tracer
+		if tracer.latency.Count() == 0 {
+			continue
+		}
+
+		tracer.occupancy.update(now)
+
+		name := t.translator.Name()
+		r.AddMetric(name, "translation_count",
+			float64(tracer.latency.Count()), "count")
+		r.AddMetric(name, "inflight_translation_avg",
+			tracer.occupancy.average(duration), "count")
+		r.AddMetric(name, "inflight_translation_max",
+			float64(tracer.occupancy.max), "count")
+		r.AddMetric(name, "translation_average_latency",
+			float64(tracer.latency.Mean()), "second")
+		tracer.latency.Report(r, name, "translation_latency")
+	}
+}
+
+func (c *addressTranslatorCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters, Metric{
+			Location: t.translator.Name(),
+			What:     "translation_count",
+			Value:    float64(t.tracer.latency.Count()),
+			Unit:     "count",
+		})
+	}
+
+	return counters
+}
+
+// mmuCollector measures the page walks of the MMUs.
+type mmuCollector struct {
+	tracers []*mmuTracer
+}
+
+func newMMUCollector(opts Options) Collector {
+	if !opts.ReportMMU {
+		return nil
+	}
+
+	return &mmuCollector{}
+}
+
+func (c *mmuCollector) Inject(r *Reporter) {
+	s := r.simulation
+
+	for _, comp := range SelectComponents(s, r.Names.MMU, isMMU) {
+		tracer := newPageWalkTracer(s.GetEngine(), r.injectTime)
+		c.tracers = append(c.tracers,
+			&mmuTracer{
+				mmu:    comp,
+				tracer: tracer,
+			})
+		tracing.CollectTrace(comp, tracer)
+
+		if owner, ok := comp.(sim.Component); ok {
+			for _, port := range owner.Ports() {
+				port.AcceptHook(tracer)
+			}
+		}
+	}
+}
+
+func (c *mmuCollector) Report(r *Reporter) {
+	now := r.simulation.GetEngine().CurrentTime()
+	duration := float64(now - r.injectTime)
+
+	for _, t := range c.tracers {
+		tracer := t.tracer
+		if tracer.walkLatency.Count() == 0 && tracer.queueLatency.Count() == 0 {
+			continue
+		}
+
+		tracer.queue.update(now)
+		tracer.walking.update(now)
+
+		name := t.mmu.Name()
+		r.AddMetric(name, "page_walk_count",
+			float64(tracer.walkLatency.Count()), "count")
+		r.AddMetric(name, "inflight_walk_avg",
+			tracer.walking.average(duration), "count")
+		r.AddMetric(name, "inflight_walk_max",
+			float64(tracer.walking.max), "count")
+		r.AddMetric(name, "page_walk_average_latency",
+			float64(tracer.walkLatency.Mean()), "second")
+		tracer.walkLatency.Report(r, name, "page_walk_latency")
+		r.AddMetric(name, "queue_length_avg",
+			tracer.queue.average(duration), "count")
+		r.AddMetric(name, "queue_length_max",
+			float64(tracer.queue.max), "count")
+		r.AddMetric(name, "queue_average_latency",
+			float64(tracer.queueLatency.Mean()), "second")
+		tracer.queueLatency.Report(r, name, "queue_latency")
+	}
+}
+
+func (c *mmuCollector) Counters() []Metric {
+	var counters []Metric
+
+	for _, t := range c.tracers {
+		counters = append(counters, Metric{
+			Location: t.mmu.Name(),
+			What:     "page_walk_count",
+			Value:    float64(t.tracer.walkLatency.Count()),
+			Unit:     "count",
+		})
+	}
+
+	return counters
+}
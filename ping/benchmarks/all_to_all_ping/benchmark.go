@@ -0,0 +1,201 @@
+// Package all_to_all_ping contains a benchmark in which every pinger pings
+// every other pinger.
+package all_to_all_ping
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+	"github.com/sarchlab/yuzawa_example/ping/pinger"
+)
+
+// The Benchmark struct is the benchmark in which every pinger pings every
+// other pinger.
+type Benchmark struct {
+	Name           string
+	simulation     *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	pingerNames    []string
+	numPings       int
+	stagger        sim.VTimeInSec
+	interval       sim.VTimeInSec
+	window         int
+
+	pingers     map[string]*pinger.Comp
+	pendingDsts map[string][]string
+}
+
+// Run runs the all-to-all ping benchmark.
+func (b *Benchmark) Run() {
+	// Set up metrics reporter
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.simulation, b.metricsOptions)
+	if err != nil {
+		log.Panicf("cannot create the metrics reporter: %v", err)
+	}
+	metricsReporter.InjectTracers()
+
+	engine := b.simulation.GetEngine()
+
+	b.pingers = make(map[string]*pinger.Comp)
+	b.pendingDsts = make(map[string][]string)
+	for _, name := range b.pingerNames {
+		b.pingers[name] = b.simulation.GetComponentByName(name).(*pinger.Comp)
+	}
+
+	// Each pinger pings the others in turns, so that no pair is favored.
+	for _, src := range b.pingerNames {
+		for i := 0; i < b.numPings; i++ {
+			for _, dst := range b.pingerNames {
+				if dst != src {
+					b.pendingDsts[src] = append(b.pendingDsts[src], dst)
+				}
+			}
+		}
+	}
+
+	for i, src := range b.pingerNames {
+		start := engine.CurrentTime() + b.stagger*sim.VTimeInSec(i)
+
+		if b.window == 0 {
+			b.issueAll(src, start)
+			continue
+		}
+
+		b.pingers[src].AcceptHook(&closedLoopHook{benchmark: b})
+		for j := 0; j < b.window; j++ {
+			b.issueNext(src, start+b.interval*sim.VTimeInSec(j))
+		}
+	}
+
+	err = engine.Run()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("End time: %.10f seconds\n",
+		engine.CurrentTime())
+
+	b.reportLatencyMatrix(metricsReporter)
+	b.reportThroughput(metricsReporter)
+
+	// Report metrics before completing
+	metricsReporter.Report()
+}
+
+// issueAll schedules all the pings of a pinger at fixed intervals from the
+// start time.
+func (b *Benchmark) issueAll(src string, start sim.VTimeInSec) {
+	for i := 0; len(b.pendingDsts[src]) > 0; i++ {
+		b.issueNext(src, start+b.interval*sim.VTimeInSec(i))
+	}
+}
+
+// issueNext schedules the next ping of a pinger at the given time.
+func (b *Benchmark) issueNext(src string, time sim.VTimeInSec) {
+	dsts := b.pendingDsts[src]
+	if len(dsts) == 0 {
+		return
+	}
+
+	dst := dsts[0]
+	b.pendingDsts[src] = dsts[1:]
+
+	evt := pinger.NewPingEvent(b.pingers[src], b.pingers[dst], time)
+	b.simulation.GetEngine().Schedule(evt)
+}
+
+// closedLoopHook issues a new ping whenever a ping of the pinger completes or
+// fails, so that the number of outstanding pings stays at the window size.
+type closedLoopHook struct {
+	benchmark *Benchmark
+}
+
+func (h *closedLoopHook) Func(ctx sim.HookCtx) {
+	if ctx.Pos != pinger.HookPosPingComplete && ctx.Pos != pinger.HookPosPingFail {
+		return
+	}
+
+	src := ctx.Domain.(*pinger.Comp)
+	h.benchmark.issueNext(src.Name(), src.CurrentTime()+h.benchmark.interval)
+}
+
+type reporter interface {
+	AddMetric(location, what string, value float64, unit string)
+}
+
+func (b *Benchmark) reportLatencyMatrix(r reporter) {
+	fmt.Printf("%-12s", "src\\dst")
+	for _, dst := range b.pingerNames {
+		fmt.Printf(" %14s", dst)
+	}
+	fmt.Printf("\n")
+
+	for _, src := range b.pingerNames {
+		fmt.Printf("%-12s", src)
+
+		for _, dst := range b.pingerNames {
+			if dst == src {
+				fmt.Printf(" %14s", "-")
+				continue
+			}
+
+			total := sim.VTimeInSec(0)
+			count := 0
+			for _, result := range b.pingers[src].CompletedPings {
+				if result.Target == dst {
+					total += result.Latency()
+					count++
+				}
+			}
+
+			if count == 0 {
+				fmt.Printf(" %14s", "n/a")
+				continue
+			}
+
+			avg := float64(total) / float64(count)
+			fmt.Printf(" %14.10f", avg)
+
+			r.AddMetric(src, "ping_latency."+dst, avg, "second")
+		}
+
+		fmt.Printf("\n")
+	}
+}
+
+func (b *Benchmark) reportThroughput(r reporter) {
+	for _, name := range b.pingerNames {
+		p := b.pingers[name]
+
+		r.AddMetric(name, "completed_pings",
+			float64(len(p.CompletedPings)), "count")
+		r.AddMetric(name, "failed_pings",
+			float64(len(p.FailedPings)), "count")
+		r.AddMetric(name, "relayed_msgs",
+			float64(p.NumRelayed), "count")
+
+		if len(p.CompletedPings) == 0 {
+			continue
+		}
+
+		start := p.CompletedPings[0].SendTime
+		end := p.CompletedPings[0].ReturnTime
+		for _, result := range p.CompletedPings {
+			start = min(start, result.SendTime)
+			end = max(end, result.ReturnTime)
+		}
+
+		if end <= start {
+			continue
+		}
+
+		throughput := float64(len(p.CompletedPings)) / float64(end-start)
+		fmt.Printf("%s: %d pings completed, %.2f pings/second\n",
+			name, len(p.CompletedPings), throughput)
+
+		r.AddMetric(name, "ping_throughput", throughput, "ping/second")
+	}
+}
@@ -0,0 +1,90 @@
+package all_to_all_ping
+
+import (
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+)
+
+// A Builder can build a benchmark
+type Builder struct {
+	simulation     *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	pingerNames    []string
+	numPings       int
+	stagger        sim.VTimeInSec
+	interval       sim.VTimeInSec
+	window         int
+}
+
+// MakeBuilder creates a new builder
+func MakeBuilder() *Builder {
+	return &Builder{
+		numPings:       1,
+		stagger:        1e-9,
+		interval:       1e-9,
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
+}
+
+// WithSimulation sets the simulation for the builder
+func (b *Builder) WithSimulation(simulation *simulation.Simulation) *Builder {
+	b.simulation = simulation
+	return b
+}
+
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
+// WithPingers sets the pingers that ping each other
+func (b *Builder) WithPingers(pingers []string) *Builder {
+	b.pingerNames = pingers
+	return b
+}
+
+// WithNumPings sets the number of pings that each pinger sends to each of the
+// other pingers
+func (b *Builder) WithNumPings(num int) *Builder {
+	b.numPings = num
+	return b
+}
+
+// WithStagger sets the time between the starts of two consecutive pingers,
+// so that the i-th pinger issues its first ping at i times the stagger.
+func (b *Builder) WithStagger(stagger sim.VTimeInSec) *Builder {
+	b.stagger = stagger
+	return b
+}
+
+// WithInterval sets the time between two pings issued by the same pinger. In
+// the closed-loop mode, it is the time that a pinger waits after a ping
+// completes before it issues the next one.
+func (b *Builder) WithInterval(interval sim.VTimeInSec) *Builder {
+	b.interval = interval
+	return b
+}
+
+// WithWindow sets the maximum number of outstanding pings per pinger, which
+// turns on the closed-loop mode. A window of 0 issues all the pings at fixed
+// intervals regardless of the responses.
+func (b *Builder) WithWindow(window int) *Builder {
+	b.window = window
+	return b
+}
+
+// Build builds the benchmark
+func (b *Builder) Build(name string) *Benchmark {
+	return &Benchmark{
+		Name:           name,
+		simulation:     b.simulation,
+		metricsOptions: b.metricsOptions,
+		pingerNames:    b.pingerNames,
+		numPings:       b.numPings,
+		stagger:        b.stagger,
+		interval:       b.interval,
+		window:         b.window,
+	}
+}
@@ -8,16 +8,20 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/polybench/atax"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim    *simulation.Simulation
-	nx, ny int
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	nx, ny         int
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation to use.
@@ -26,6 +30,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithNx sets the first dimension size.
 func (b *Builder) WithNx(nx int) *Builder {
 	b.nx = nx
@@ -76,8 +86,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	bm.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name: name,
-		sim:  b.sim,
-		atax: bm,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		atax:           bm,
 	}
 }
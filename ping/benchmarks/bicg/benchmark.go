@@ -10,9 +10,10 @@ import (
 )
 
 type Benchmark struct {
-	name string
-	sim  *simulation.Simulation
-	bicg *bicg.Benchmark
+	name           string
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	bicg           *bicg.Benchmark
 }
 
 func (b *Benchmark) Run() {
@@ -26,7 +27,11 @@ func (b *Benchmark) Run() {
 	}
 	d := driverComp.(*driver.Driver)
 
-	metricsReporter := metrics_reporter.NewReporter(b.sim)
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.sim, b.metricsOptions)
+	if err != nil {
+		log.Panicf("cannot create the metrics reporter: %v", err)
+	}
+	metricsReporter.InjectTracers()
 	d.Run()
 
 	b.bicg.Run()
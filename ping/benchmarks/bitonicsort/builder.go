@@ -8,17 +8,21 @@ import (
 	mgpusimbitonic "github.com/sarchlab/mgpusim/v4/amd/benchmarks/amdappsdk/bitonicsort"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
 	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
 	length         int
 	orderAscending bool
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation to use.
@@ -27,6 +31,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithLength sets the number of elements to sort (should be power of 2).
 func (b *Builder) WithLength(n int) *Builder {
 	b.length = n
@@ -77,8 +87,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	bm.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name:   name,
-		sim:    b.sim,
-		bitonic: bm,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		bitonic:        bm,
 	}
 }
@@ -8,17 +8,20 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/amdappsdk/fastwalshtransform"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim    *simulation.Simulation
-	length uint32
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	length         uint32
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
 	return &Builder{
-		length: 256,
+		length:         256,
+		metricsOptions: metrics_reporter.DefaultOptions(),
 	}
 }
 
@@ -28,6 +31,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithLength sets the transform length (must be power of 2).
 func (b *Builder) WithLength(l uint32) *Builder {
 	b.length = l
@@ -71,8 +80,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	fwt.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name: name,
-		sim:  b.sim,
-		fwt:  fwt,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		fwt:            fwt,
 	}
 }
@@ -2,18 +2,23 @@ package ideal_mem_controller
 
 import (
 	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+	"github.com/sarchlab/yuzawa_example/ping/memaccessagent"
 )
 
 // Builder helps in setting up the memory controller simulation.
 type Builder struct {
-	simulation *simulation.Simulation
-	numAccess int
-	maxAddress uint64
+	simulation     *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	agentConfig    memaccessagent.Config
 }
 
 // MakeBuilder creates a new Builder.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+		agentConfig:    memaccessagent.DefaultConfig(),
+	}
 }
 
 // WithSimulation sets the simulation for the benchmark.
@@ -22,24 +27,37 @@ func (b *Builder) WithSimulation(s *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
+// WithAgentConfig sets how the agent issues its accesses. It replaces the
+// number of accesses and the maximum address set earlier.
+func (b *Builder) WithAgentConfig(c memaccessagent.Config) *Builder {
+	b.agentConfig = c
+	return b
+}
+
 // WithNumAccess sets the number of memory access requests.
 func (b *Builder) WithNumAccess(n int) *Builder {
-	b.numAccess = n
+	b.agentConfig.NumAccess = n
 	return b
 }
 
 // WithMaxAddress sets the maximum address for the memory access requests.
 func (b *Builder) WithMaxAddress(a uint64) *Builder {
-	b.maxAddress = a
+	b.agentConfig.MaxAddress = a
 	return b
 }
 
 // Build creates a new Benchmark.
 func (b *Builder) Build(name string) *Benchmark {
 	return &Benchmark{
-		Name:              name,
-		simulation: b.simulation,
-		numAccess: b.numAccess,
-		maxAddress: b.maxAddress,
+		Name:           name,
+		simulation:     b.simulation,
+		metricsOptions: b.metricsOptions,
+		agentConfig:    b.agentConfig,
 	}
-}
\ No newline at end of file
+}
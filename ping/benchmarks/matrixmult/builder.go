@@ -8,16 +8,20 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/amdappsdk/matrixmultiplication"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim    *simulation.Simulation
-	x, y, z uint32
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	x, y, z        uint32
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation to use.
@@ -26,6 +30,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithX sets the height of the first matrix (M in M×K * K×N).
 func (b *Builder) WithX(x uint32) *Builder {
 	b.x = x
@@ -83,9 +93,10 @@ func (b *Builder) Build(name string) *Benchmark {
 	mm.SelectGPU([]int{1})
 
 	bm := &Benchmark{
-		name: name,
-		sim:  b.sim,
-		mm:   mm,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		mm:             mm,
 	}
 	return bm
 }
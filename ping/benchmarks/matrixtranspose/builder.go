@@ -8,16 +8,20 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/amdappsdk/matrixtranspose"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim   *simulation.Simulation
-	width int
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	width          int
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation to use.
@@ -26,6 +30,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithWidth sets the dimension of the square matrix (Width x Width).
 func (b *Builder) WithWidth(w int) *Builder {
 	b.width = w
@@ -69,9 +79,10 @@ func (b *Builder) Build(name string) *Benchmark {
 	mt.SelectGPU([]int{1})
 
 	bm := &Benchmark{
-		name: name,
-		sim:  b.sim,
-		mt:   mt,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		mt:             mt,
 	}
 	return bm
 }
@@ -3,6 +3,7 @@ package multi_ping
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/simulation"
@@ -14,6 +15,7 @@ import (
 type Benchmark struct {
 	Name              string
 	simulation        *simulation.Simulation
+	metricsOptions    metrics_reporter.Options
 	senderNames       []string
 	receiverName      string
 	numPingsPerSender int
@@ -22,7 +24,11 @@ type Benchmark struct {
 // Run runs the multi-ping benchmark.
 func (b *Benchmark) Run() {
 	// Set up metrics reporter
-	metricsReporter := metrics_reporter.NewReporter(b.simulation)
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.simulation, b.metricsOptions)
+	if err != nil {
+		log.Panicf("cannot create the metrics reporter: %v", err)
+	}
+	metricsReporter.InjectTracers()
 
 	engine := b.simulation.GetEngine()
 	receiver := b.simulation.GetComponentByName(b.receiverName)
@@ -43,6 +49,19 @@ func (b *Benchmark) Run() {
 	fmt.Printf("End time: %.10f seconds\n",
 		engine.CurrentTime())
 
+	for _, senderName := range b.senderNames {
+		sender := b.simulation.GetComponentByName(senderName).(*pinger.Comp)
+		for _, r := range sender.CompletedPings {
+			fmt.Printf("Ping %s -> %s: %d hops, latency %.10f seconds\n",
+				senderName, r.Target, r.ReqHops+r.RspHops, r.Latency())
+		}
+
+		for _, f := range sender.FailedPings {
+			fmt.Printf("Ping %s -> %s: failed after %d attempts\n",
+				senderName, f.Target, f.Attempts)
+		}
+	}
+
 	// Report metrics before completing
 	metricsReporter.Report()
 }
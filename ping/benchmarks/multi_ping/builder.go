@@ -1,10 +1,14 @@
 package multi_ping
 
-import "github.com/sarchlab/akita/v4/simulation"
+import (
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+)
 
 // A Builder can build a benchmark
 type Builder struct {
 	simulation        *simulation.Simulation
+	metricsOptions    metrics_reporter.Options
 	senderNames       []string
 	receiverName      string
 	numPingsPerSender int
@@ -12,7 +16,9 @@ type Builder struct {
 
 // MakeBuilder creates a new builder
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation for the builder
@@ -21,6 +27,12 @@ func (b *Builder) WithSimulation(simulation *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithSenders sets multiple senders for the builder
 func (b *Builder) WithSenders(senders []string) *Builder {
 	b.senderNames = senders
@@ -44,6 +56,7 @@ func (b *Builder) Build(name string) *Benchmark {
 	return &Benchmark{
 		Name:              name,
 		simulation:        b.simulation,
+		metricsOptions:    b.metricsOptions,
 		senderNames:       b.senderNames,
 		receiverName:      b.receiverName,
 		numPingsPerSender: b.numPingsPerSender,
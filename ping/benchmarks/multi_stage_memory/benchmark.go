@@ -9,48 +9,91 @@ import (
 )
 
 type Benchmark struct {
-	name       string
-	simulation *simulation.Simulation
-	numAccess  int
-	maxAddress uint64
+	name           string
+	simulation     *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	agentConfig    memaccessagent.Config
 
 	ioMMUName string
+	atName    string
 }
 
-func (b *Benchmark) Run() {
+// Run executes the benchmark. It returns an error if the benchmark cannot be
+// set up, if the simulation fails, or if the agent does not complete its
+// accesses correctly.
+func (b *Benchmark) Run() error {
 	// Set up metrics reporter
-	metricsReporter := metrics_reporter.NewReporter(b.simulation)
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.simulation, b.metricsOptions)
+	if err != nil {
+		return err
+	}
+	metricsReporter.InjectTracers()
+
+	// The metrics are reported even if the benchmark fails, so that the sinks
+	// are flushed and closed.
+	defer metricsReporter.Report()
 
 	engine := b.simulation.GetEngine()
-	agent := b.simulation.GetComponentByName("MemAgent").(*memaccessagent.MemAccessAgent)
+	agent, ok := b.simulation.GetComponentByName("MemAgent").(*memaccessagent.MemAccessAgent)
+	if !ok {
+		return fmt.Errorf("MemAgent component not found in simulation")
+	}
 
 	iommu := b.simulation.GetComponentByName(b.ioMMUName)
 	if iommu == nil {
-		panic("IoMMU component not found in simulation")
+		return fmt.Errorf("IoMMU component %q not found in simulation",
+			b.ioMMUName)
+	}
+
+	if err := b.agentConfig.ApplyTo(agent); err != nil {
+		return err
+	}
+	defer agent.Close()
+
+	if agent.UseVirtualAddress && b.atName != "" {
+		at := b.simulation.GetComponentByName(b.atName)
+		if at == nil {
+			return fmt.Errorf(
+				"address translator component %q not found in simulation",
+				b.atName)
+		}
+
+		agent.CheckTranslation(at.GetPortByName("Bottom"))
 	}
 
-	agent.WriteLeft = b.numAccess
-	agent.ReadLeft = b.numAccess
-	agent.MaxAddress = b.maxAddress
-	agent.UseVirtualAddress = false
+	recorder, err := b.agentConfig.Record(agent, engine)
+	if err != nil {
+		return err
+	}
 
 	agent.TickLater()
-	err := engine.Run()
+	err = engine.Run()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("simulation failed at %.10f: %w",
+			engine.CurrentTime(), err)
+	}
+
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			return fmt.Errorf("cannot write the recorded trace: %w", err)
+		}
+	}
+
+	if err := agent.Err(); err != nil {
+		return err
 	}
 
-	if len(agent.PendingWriteReq) > 0 || len(agent.PendingReadReq) > 0 {
-		panic(fmt.Errorf("there are still pending requests"))
+	if err := agent.CompletionError(); err != nil {
+		return err
 	}
 
-	if agent.WriteLeft > 0 || agent.ReadLeft > 0 {
-		panic(fmt.Errorf("there are still requests left"))
+	if err := agent.VerificationError(); err != nil {
+		return err
 	}
 
-	fmt.Printf("End time: %.10f seconds\n",
-		engine.CurrentTime())
+	agent.PrintSummary()
+
+	agent.ReportMetrics(metricsReporter)
 
-	// Report metrics before completing
-	metricsReporter.Report()
+	return nil
 }
@@ -8,19 +8,23 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/amdappsdk/nbody"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 const nbodyGroupSize = 256
 
 type Builder struct {
-	sim             *simulation.Simulation
-	numParticles    int
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	numParticles   int
 	numIterations  int
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation to use.
@@ -29,6 +33,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithNumParticles sets the number of particles.
 func (b *Builder) WithNumParticles(n int) *Builder {
 	b.numParticles = n
@@ -84,8 +94,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	bm.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name:   name,
-		sim:    b.sim,
-		nbody:  bm,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		nbody:          bm,
 	}
 }
@@ -4,23 +4,26 @@ import (
 	"fmt"
 
 	"github.com/sarchlab/akita/v4/mem/mem"
-	mgpusimnw "github.com/sarchlab/mgpusim/v4/amd/benchmarks/rodinia/nw"
 	"github.com/sarchlab/akita/v4/simulation"
+	mgpusimnw "github.com/sarchlab/mgpusim/v4/amd/benchmarks/rodinia/nw"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim     *simulation.Simulation
-	length  int
-	penalty int
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	length         int
+	penalty        int
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
 	return &Builder{
-		length:  256,
-		penalty: 10,
+		length:         256,
+		penalty:        10,
+		metricsOptions: metrics_reporter.DefaultOptions(),
 	}
 }
 
@@ -30,6 +33,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithLength sets the sequence length for Needleman-Wunsch.
 func (b *Builder) WithLength(l int) *Builder {
 	b.length = l
@@ -80,8 +89,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	nwbm.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name: name,
-		sim:  b.sim,
-		nw:   nwbm,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		nw:             nwbm,
 	}
 }
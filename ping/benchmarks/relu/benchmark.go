@@ -10,9 +10,10 @@ import (
 )
 
 type Benchmark struct {
-	name string
-	sim  *simulation.Simulation
-	relu *relu.Benchmark
+	name           string
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	relu           *relu.Benchmark
 }
 
 func (b *Benchmark) Run() {
@@ -27,7 +28,11 @@ func (b *Benchmark) Run() {
 	d := driverComp.(*driver.Driver)
 
 	// Set up metrics reporter (standalone version of mgpusim's reporter)
-	metricsReporter := metrics_reporter.NewReporter(b.sim)
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.sim, b.metricsOptions)
+	if err != nil {
+		log.Panicf("cannot create the metrics reporter: %v", err)
+	}
+	metricsReporter.InjectTracers()
 
 	// Start the driver
 	d.Run()
@@ -8,16 +8,20 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/dnn/layer_benchmarks/relu"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim    *simulation.Simulation
-	length int
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	length         int
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation to use.
@@ -26,6 +30,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithLength sets the number of element to perform ReLU operation on.
 func (b *Builder) WithLength(l int) *Builder {
 	b.length = l
@@ -70,9 +80,10 @@ func (b *Builder) Build(name string) *Benchmark {
 	r.SelectGPU([]int{1})
 
 	bm := &Benchmark{
-		name: name,
-		sim:  b.sim,
-		relu: r,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		relu:           r,
 	}
 
 	return bm
@@ -0,0 +1,120 @@
+// Package shared_memory contains a benchmark in which several memory access
+// agents share a memory and check each other's writes.
+package shared_memory
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+	"github.com/sarchlab/yuzawa_example/ping/memaccessagent"
+)
+
+// The Benchmark struct is the benchmark in which several agents share a
+// memory.
+type Benchmark struct {
+	Name            string
+	simulation      *simulation.Simulation
+	metricsOptions  metrics_reporter.Options
+	agentNames      []string
+	numAccess       int
+	maxAddress      uint64
+	sharing         string
+	readRatio       float64
+	allowStaleReads bool
+
+	agents []*memaccessagent.MemAccessAgent
+}
+
+// Run runs the shared memory benchmark. It returns an error if the benchmark
+// cannot be set up, if the simulation fails, or if an agent does not complete
+// its accesses correctly.
+func (b *Benchmark) Run() error {
+	// Set up metrics reporter
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.simulation, b.metricsOptions)
+	if err != nil {
+		return err
+	}
+	metricsReporter.InjectTracers()
+
+	// The metrics are reported even if the benchmark fails, so that the sinks
+	// are flushed and closed.
+	defer metricsReporter.Report()
+
+	engine := b.simulation.GetEngine()
+	golden := memaccessagent.NewGoldenMemory()
+
+	b.agents = nil
+	for i, name := range b.agentNames {
+		agent, ok := b.simulation.GetComponentByName(name).(*memaccessagent.MemAccessAgent)
+		if !ok {
+			return fmt.Errorf("%s component not found in simulation", name)
+		}
+
+		g, err := memaccessagent.NewSharingGenerator(
+			b.sharing, i, len(b.agentNames), b.maxAddress, agent.Rand)
+		if err != nil {
+			return err
+		}
+
+		agent.Memory = golden
+		agent.AddressGenerator = g
+		agent.MaxAddress = b.maxAddress
+		agent.WriteLeft = b.numAccess
+		agent.ReadLeft = b.numAccess
+		agent.ReadRatio = b.readRatio
+		agent.TickLater()
+
+		b.agents = append(b.agents, agent)
+	}
+
+	err = engine.Run()
+	if err != nil {
+		return fmt.Errorf("simulation failed at %.10f: %w",
+			engine.CurrentTime(), err)
+	}
+
+	fmt.Printf("End time: %.10f seconds\n",
+		engine.CurrentTime())
+
+	b.report(metricsReporter)
+
+	return b.checkCorrectness()
+}
+
+func (b *Benchmark) report(r memaccessagent.MetricReporter) {
+	fmt.Printf("%-12s %10s %12s %12s\n",
+		"agent", "accesses", "stale reads", "wrong reads")
+
+	for _, agent := range b.agents {
+		stale := agent.NumStaleReads()
+		wrong := len(agent.ReadMismatches) - stale
+
+		fmt.Printf("%-12s %10d %12d %12d\n",
+			agent.Name(), agent.NumCompleted, stale, wrong)
+
+		r.AddMetric(agent.Name(), "stale_reads", float64(stale), "count")
+		r.AddMetric(agent.Name(), "wrong_reads", float64(wrong), "count")
+		agent.ReportMetrics(r)
+	}
+}
+
+func (b *Benchmark) checkCorrectness() error {
+	for _, agent := range b.agents {
+		if err := agent.CompletionError(); err != nil {
+			return err
+		}
+
+		stale := agent.NumStaleReads()
+		if b.allowStaleReads && stale == len(agent.ReadMismatches) &&
+			len(agent.TranslationMismatches) == 0 {
+			continue
+		}
+
+		if err := agent.VerificationError(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package shared_memory
+
+import (
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+)
+
+// A Builder can build a benchmark
+type Builder struct {
+	simulation      *simulation.Simulation
+	metricsOptions  metrics_reporter.Options
+	agentNames      []string
+	numAccess       int
+	maxAddress      uint64
+	sharing         string
+	readRatio       float64
+	allowStaleReads bool
+}
+
+// MakeBuilder creates a new builder
+func MakeBuilder() *Builder {
+	return &Builder{
+		numAccess:      1000,
+		maxAddress:     1024 * 1024,
+		sharing:        "mixed",
+		readRatio:      0.5,
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
+}
+
+// WithSimulation sets the simulation for the builder
+func (b *Builder) WithSimulation(simulation *simulation.Simulation) *Builder {
+	b.simulation = simulation
+	return b
+}
+
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
+// WithAgents sets the memory access agents that share the memory
+func (b *Builder) WithAgents(agents []string) *Builder {
+	b.agentNames = agents
+	return b
+}
+
+// WithNumAccess sets the number of reads and the number of writes of each
+// agent
+func (b *Builder) WithNumAccess(n int) *Builder {
+	b.numAccess = n
+	return b
+}
+
+// WithMaxAddress sets the size of the shared memory
+func (b *Builder) WithMaxAddress(a uint64) *Builder {
+	b.maxAddress = a
+	return b
+}
+
+// WithSharing sets how the agents share the memory. See
+// memaccessagent.NewSharingGenerator for the supported modes.
+func (b *Builder) WithSharing(mode string) *Builder {
+	b.sharing = mode
+	return b
+}
+
+// WithReadRatio sets the probability that an access is a read
+func (b *Builder) WithReadRatio(ratio float64) *Builder {
+	b.readRatio = ratio
+	return b
+}
+
+// WithStaleReadsAllowed sets whether the benchmark tolerates stale reads, as
+// returned by caches that are not coherent. Other wrong reads always fail the
+// benchmark.
+func (b *Builder) WithStaleReadsAllowed(allow bool) *Builder {
+	b.allowStaleReads = allow
+	return b
+}
+
+// Build builds the benchmark
+func (b *Builder) Build(name string) *Benchmark {
+	return &Benchmark{
+		Name:            name,
+		simulation:      b.simulation,
+		metricsOptions:  b.metricsOptions,
+		agentNames:      b.agentNames,
+		numAccess:       b.numAccess,
+		maxAddress:      b.maxAddress,
+		sharing:         b.sharing,
+		readRatio:       b.readRatio,
+		allowStaleReads: b.allowStaleReads,
+	}
+}
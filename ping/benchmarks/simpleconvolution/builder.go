@@ -8,21 +8,24 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks/amdappsdk/simpleconvolution"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim      *simulation.Simulation
-	width    uint32
-	height   uint32
-	maskSize uint32
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	width          uint32
+	height         uint32
+	maskSize       uint32
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
 	return &Builder{
-		width:    256,
-		height:   256,
-		maskSize: 3,
+		width:          256,
+		height:         256,
+		maskSize:       3,
+		metricsOptions: metrics_reporter.DefaultOptions(),
 	}
 }
 
@@ -32,6 +35,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithWidth sets the image width.
 func (b *Builder) WithWidth(w uint32) *Builder {
 	b.width = w
@@ -89,8 +98,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	sc.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name: name,
-		sim:  b.sim,
-		sc:   sc,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		sc:             sc,
 	}
 }
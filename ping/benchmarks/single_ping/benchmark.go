@@ -3,6 +3,7 @@ package single_ping
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/yuzawa_example/metrics_reporter"
@@ -11,16 +12,21 @@ import (
 
 // The Benchmark struct is the benchmark that perform ping once.
 type Benchmark struct {
-	Name         string
-	simulation   *simulation.Simulation
-	senderNames  []string
-	receiverName string
+	Name           string
+	simulation     *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	senderNames    []string
+	receiverName   string
 }
 
 // Run runs the benchmark.
 func (b *Benchmark) Run() {
 	// Set up metrics reporter
-	metricsReporter := metrics_reporter.NewReporter(b.simulation)
+	metricsReporter, err := metrics_reporter.NewReporterWithOptions(b.simulation, b.metricsOptions)
+	if err != nil {
+		log.Panicf("cannot create the metrics reporter: %v", err)
+	}
+	metricsReporter.InjectTracers()
 
 	engine := b.simulation.GetEngine()
 	senders := b.simulation.GetComponentByName(b.senderNames[0])
@@ -34,6 +40,16 @@ func (b *Benchmark) Run() {
 	fmt.Printf("End time: %.10f seconds\n",
 		engine.CurrentTime())
 
+	for _, r := range senders.(*pinger.Comp).CompletedPings {
+		fmt.Printf("Ping %s -> %s: %d hops, latency %.10f seconds\n",
+			b.senderNames[0], r.Target, r.ReqHops+r.RspHops, r.Latency())
+	}
+
+	for _, f := range senders.(*pinger.Comp).FailedPings {
+		fmt.Printf("Ping %s -> %s: failed after %d attempts\n",
+			b.senderNames[0], f.Target, f.Attempts)
+	}
+
 	// Report metrics before completing
 	metricsReporter.Report()
 }
@@ -1,21 +1,24 @@
 package single_ping
 
-import(
+import (
 	"github.com/sarchlab/akita/v4/simulation"
-) 
-
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+)
 
 // A Builder can build a benchmark
 type Builder struct {
-	Name         string
-	simulation   *simulation.Simulation
-	senderNames  []string
-	receiverName string
+	Name           string
+	simulation     *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	senderNames    []string
+	receiverName   string
 }
 
 // MakeBuilder creates a new builder
 func MakeBuilder() *Builder {
-	return &Builder{}
+	return &Builder{
+		metricsOptions: metrics_reporter.DefaultOptions(),
+	}
 }
 
 // WithSimulation sets the simulation for the builder
@@ -24,6 +27,12 @@ func (b *Builder) WithSimulation(simulation *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithSender sets the sender for the builder
 func (b *Builder) WithSender(senders []string) *Builder {
 	b.senderNames = senders
@@ -39,9 +48,10 @@ func (b *Builder) WithReceiver(receiver string) *Builder {
 // Build builds the benchmark
 func (b *Builder) Build(name string) *Benchmark {
 	return &Benchmark{
-		Name:         name,
-		simulation:   b.simulation,
-		senderNames:  b.senderNames,
-		receiverName: b.receiverName,
+		Name:           name,
+		simulation:     b.simulation,
+		metricsOptions: b.metricsOptions,
+		senderNames:    b.senderNames,
+		receiverName:   b.receiverName,
 	}
 }
@@ -6,21 +6,24 @@ import (
 	mgpusimstencil "github.com/sarchlab/mgpusim/v4/amd/benchmarks/shoc/stencil2d"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	sim          *simulation.Simulation
-	numRows      int
-	numCols      int
-	numIteration int
+	sim            *simulation.Simulation
+	metricsOptions metrics_reporter.Options
+	numRows        int
+	numCols        int
+	numIteration   int
 }
 
 // MakeBuilder creates a builder with default parameters.
 func MakeBuilder() *Builder {
 	return &Builder{
-		numRows:      256,
-		numCols:      256,
-		numIteration: 10,
+		numRows:        256,
+		numCols:        256,
+		numIteration:   10,
+		metricsOptions: metrics_reporter.DefaultOptions(),
 	}
 }
 
@@ -30,6 +33,12 @@ func (b *Builder) WithSimulation(sim *simulation.Simulation) *Builder {
 	return b
 }
 
+// WithMetricsOptions sets the metric families that are reported.
+func (b *Builder) WithMetricsOptions(opts metrics_reporter.Options) *Builder {
+	b.metricsOptions = opts
+	return b
+}
+
 // WithNumRows sets the number of rows.
 func (b *Builder) WithNumRows(n int) *Builder {
 	b.numRows = n
@@ -74,8 +83,9 @@ func (b *Builder) Build(name string) *Benchmark {
 	bm.SelectGPU([]int{1})
 
 	return &Benchmark{
-		name:      name,
-		sim:       b.sim,
-		stencil2d: bm,
+		name:           name,
+		sim:            b.sim,
+		metricsOptions: b.metricsOptions,
+		stencil2d:      bm,
 	}
 }
@@ -0,0 +1,71 @@
+package lossyconnection
+
+import (
+	"math/rand"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// Builder can build lossy connections.
+type Builder struct {
+	engine    sim.Engine
+	freq      sim.Freq
+	dropRate  float64
+	dropEvery int
+	seed      int64
+}
+
+// MakeBuilder creates a new builder.
+func MakeBuilder() Builder {
+	return Builder{
+		freq: 1 * sim.GHz,
+		seed: 1,
+	}
+}
+
+// WithEngine sets the engine for the builder.
+func (b Builder) WithEngine(e sim.Engine) Builder {
+	b.engine = e
+	return b
+}
+
+// WithFreq sets the frequency for the builder.
+func (b Builder) WithFreq(f sim.Freq) Builder {
+	b.freq = f
+	return b
+}
+
+// WithDropRate sets the probability that a message is dropped.
+func (b Builder) WithDropRate(rate float64) Builder {
+	b.dropRate = rate
+	return b
+}
+
+// WithDropEvery makes the connection drop every n-th message, regardless of
+// the drop rate. A value of 0 disables the pattern.
+func (b Builder) WithDropEvery(n int) Builder {
+	b.dropEvery = n
+	return b
+}
+
+// WithSeed sets the seed of the random number generator that decides which
+// messages to drop, so that runs are reproducible.
+func (b Builder) WithSeed(seed int64) Builder {
+	b.seed = seed
+	return b
+}
+
+// Build creates a new lossy connection.
+func (b Builder) Build(name string) *Comp {
+	c := &Comp{
+		portMap:   make(map[sim.RemotePort]sim.Port),
+		dropRate:  b.dropRate,
+		dropEvery: b.dropEvery,
+		rng:       rand.New(rand.NewSource(b.seed)),
+		decisions: make(map[string]bool),
+	}
+	c.TickingComponent = sim.NewSecondaryTickingComponent(
+		name, b.engine, b.freq, c)
+
+	return c
+}
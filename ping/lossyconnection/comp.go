@@ -0,0 +1,164 @@
+// Package lossyconnection provides a connection that delivers messages like a
+// direct connection but can drop some of them, so that protocols can be
+// tested against message loss.
+package lossyconnection
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// Comp is a connection that connects components without latency and drops
+// messages either randomly or following a fixed pattern.
+type Comp struct {
+	*sim.TickingComponent
+
+	ports      []sim.Port
+	portMap    map[sim.RemotePort]sim.Port
+	nextPortID int
+
+	dropRate  float64
+	dropEvery int
+	rng       *rand.Rand
+
+	// decisions keeps whether the messages waiting at the head of the ports
+	// are to be dropped, so that a message blocked by the destination is not
+	// given another chance to be dropped every tick.
+	decisions map[string]bool
+
+	// numDecisions counts the messages that have been given a decision, so
+	// that every message has its own ordinal even when others are blocked.
+	numDecisions int
+
+	// NumDelivered is the number of messages delivered to their destination.
+	NumDelivered int
+
+	// NumDropped is the number of messages discarded by the connection.
+	NumDropped int
+}
+
+// PlugIn marks the port connects to this connection.
+func (c *Comp) PlugIn(port sim.Port) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.ports = append(c.ports, port)
+	c.portMap[port.AsRemote()] = port
+
+	port.SetConnection(c)
+}
+
+// Unplug marks the port no longer connects to this connection.
+func (c *Comp) Unplug(port sim.Port) {
+	c.Lock()
+	defer c.Unlock()
+
+	for i, p := range c.ports {
+		if p == port {
+			c.ports = append(c.ports[:i], c.ports[i+1:]...)
+			break
+		}
+	}
+
+	delete(c.portMap, port.AsRemote())
+	c.nextPortID = 0
+
+	port.SetConnection(nil)
+}
+
+// NotifyAvailable is called by a port to notify that the connection can
+// deliver to the port again.
+func (c *Comp) NotifyAvailable(p sim.Port) {
+	for _, port := range c.ports {
+		if port == p {
+			continue
+		}
+
+		port.NotifyAvailable()
+	}
+
+	c.TickNow()
+}
+
+// NotifySend is called by a port to notify that the connection can start to
+// tick now.
+func (c *Comp) NotifySend() {
+	c.TickNow()
+}
+
+// Tick drops or delivers the messages waiting in the ports.
+func (c *Comp) Tick() bool {
+	if len(c.ports) == 0 {
+		return false
+	}
+
+	madeProgress := false
+
+	for i := range c.ports {
+		portID := (i + c.nextPortID) % len(c.ports)
+		madeProgress = c.forwardMany(c.ports[portID]) || madeProgress
+	}
+
+	c.nextPortID = (c.nextPortID + 1) % len(c.ports)
+
+	return madeProgress
+}
+
+func (c *Comp) forwardMany(port sim.Port) bool {
+	madeProgress := false
+
+	for {
+		head := port.PeekOutgoing()
+		if head == nil {
+			break
+		}
+
+		if c.shouldDrop(head) {
+			port.RetrieveOutgoing()
+			delete(c.decisions, head.Meta().ID)
+			c.NumDropped++
+			madeProgress = true
+
+			continue
+		}
+
+		dstPort, found := c.portMap[head.Meta().Dst]
+		if !found {
+			panic(fmt.Sprintf("port %s not found", head.Meta().Dst))
+		}
+
+		err := dstPort.Deliver(head)
+		if err != nil {
+			break
+		}
+
+		port.RetrieveOutgoing()
+		delete(c.decisions, head.Meta().ID)
+		c.NumDelivered++
+		madeProgress = true
+	}
+
+	return madeProgress
+}
+
+// shouldDrop decides whether a message is dropped. The decision is made once,
+// when the message first reaches the head of its port.
+func (c *Comp) shouldDrop(msg sim.Msg) bool {
+	drop, decided := c.decisions[msg.Meta().ID]
+	if decided {
+		return drop
+	}
+
+	c.numDecisions++
+
+	drop = c.dropEvery > 0 && c.numDecisions%c.dropEvery == 0
+	if !drop && c.dropRate > 0 {
+		drop = c.rng.Float64() < c.dropRate
+	}
+
+	c.decisions[msg.Meta().ID] = drop
+
+	return drop
+}
@@ -0,0 +1,410 @@
+package memaccessagent
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// An AddressGenerator decides the addresses that a MemAccessAgent accesses.
+// Generated addresses must be 4-byte aligned.
+type AddressGenerator interface {
+	// NextAddress returns the address of the next access. The read argument
+	// tells if the access is a read or a write.
+	NextAddress(read bool) uint64
+}
+
+// NewAddressGenerator creates one of the built-in address generators by name,
+// using default parameters that suit an address space of maxAddress bytes.
+// The supported patterns are "sequential", "strided", "working_set", "zipf",
+// "pointer_chase", and "coalesced". The random choices are drawn from rng. It
+// returns an error if the address space is too small for the pattern.
+func NewAddressGenerator(
+	pattern string,
+	maxAddress uint64,
+	rng *rand.Rand,
+) (AddressGenerator, error) {
+	switch pattern {
+	case "sequential":
+		return NewStridedGenerator(0, 4, maxAddress)
+	case "strided":
+		return NewStridedGenerator(0, 64, maxAddress)
+	case "working_set":
+		return NewWorkingSetGenerator(0, min(maxAddress, 64*1024), rng)
+	case "zipf":
+		return NewZipfGenerator(maxAddress, 64, 1.2, rng)
+	case "pointer_chase":
+		return NewPointerChaseGenerator(
+			maxAddress, min(maxAddress/64, 1<<20), rng)
+	case "coalesced":
+		return NewCoalescedGenerator(maxAddress, 4, 64)
+	default:
+		return nil, fmt.Errorf("unknown address pattern %q", pattern)
+	}
+}
+
+func alignAddress(addr uint64) uint64 {
+	return addr / 4 * 4
+}
+
+// StridedGenerator walks through the address space with a fixed stride and
+// wraps around at the end. With a 4-byte stride, it generates sequential
+// accesses.
+type StridedGenerator struct {
+	start      uint64
+	stride     uint64
+	maxAddress uint64
+	next       uint64
+}
+
+// NewStridedGenerator creates a StridedGenerator that starts at start and
+// stays below maxAddress.
+func NewStridedGenerator(
+	start, stride, maxAddress uint64,
+) (*StridedGenerator, error) {
+	if stride == 0 || stride%4 != 0 {
+		return nil, fmt.Errorf("stride %d is not a multiple of 4", stride)
+	}
+
+	if alignAddress(start)+4 > maxAddress {
+		return nil, fmt.Errorf("start 0x%X is beyond the address space of "+
+			"%d bytes", start, maxAddress)
+	}
+
+	return &StridedGenerator{
+		start:      alignAddress(start),
+		stride:     stride,
+		maxAddress: maxAddress,
+		next:       alignAddress(start),
+	}, nil
+}
+
+// NextAddress returns the next address in the stride.
+func (g *StridedGenerator) NextAddress(_ bool) uint64 {
+	addr := g.next
+
+	g.next += g.stride
+	if g.next+4 > g.maxAddress {
+		g.next = g.start
+	}
+
+	return addr
+}
+
+// WorkingSetGenerator accesses random addresses within a region, so that the
+// footprint of the accesses is bounded.
+type WorkingSetGenerator struct {
+	base uint64
+	size uint64
+	rng  *rand.Rand
+}
+
+// NewWorkingSetGenerator creates a WorkingSetGenerator that accesses the size
+// bytes starting at base.
+func NewWorkingSetGenerator(
+	base, size uint64,
+	rng *rand.Rand,
+) (*WorkingSetGenerator, error) {
+	if size < 4 {
+		return nil, fmt.Errorf("working set of %d bytes is smaller than "+
+			"a word", size)
+	}
+
+	return &WorkingSetGenerator{
+		base: alignAddress(base),
+		size: size,
+		rng:  rng,
+	}, nil
+}
+
+// NextAddress returns a random address in the working set.
+func (g *WorkingSetGenerator) NextAddress(_ bool) uint64 {
+	return g.base + g.rng.Uint64()%(g.size/4)*4
+}
+
+// ZipfGenerator accesses blocks following a Zipf distribution, so that a few
+// hot blocks receive most of the accesses.
+type ZipfGenerator struct {
+	zipf      *rand.Zipf
+	blockSize uint64
+	rng       *rand.Rand
+}
+
+// NewZipfGenerator creates a ZipfGenerator over the blocks of blockSize bytes
+// below maxAddress. The skew s must be greater than 1; larger values
+// concentrate the accesses on fewer blocks.
+func NewZipfGenerator(
+	maxAddress, blockSize uint64,
+	s float64,
+	rng *rand.Rand,
+) (*ZipfGenerator, error) {
+	if s <= 1 {
+		return nil, fmt.Errorf("zipf skew %g is not greater than 1", s)
+	}
+
+	if blockSize < 4 || blockSize%4 != 0 {
+		return nil, fmt.Errorf("block size %d is not a multiple of 4",
+			blockSize)
+	}
+
+	numBlocks := maxAddress / blockSize
+	if numBlocks == 0 {
+		return nil, fmt.Errorf("address space of %d bytes is smaller than "+
+			"a block of %d bytes", maxAddress, blockSize)
+	}
+
+	return &ZipfGenerator{
+		zipf:      rand.NewZipf(rng, s, 1, numBlocks-1),
+		blockSize: blockSize,
+		rng:       rng,
+	}, nil
+}
+
+// NextAddress returns an address in a block picked by the Zipf distribution.
+func (g *ZipfGenerator) NextAddress(_ bool) uint64 {
+	block := g.zipf.Uint64()
+	offset := g.rng.Uint64() % (g.blockSize / 4) * 4
+
+	return block*g.blockSize + offset
+}
+
+// PointerChaseGenerator follows a random cycle that visits every node once,
+// like a linked-list traversal. Each node is at the start of an equally sized
+// slot of the address space.
+type PointerChaseGenerator struct {
+	nextNode []uint32
+	nodeSize uint64
+	current  uint32
+}
+
+// NewPointerChaseGenerator creates a PointerChaseGenerator that chases
+// numNodes nodes spread below maxAddress.
+func NewPointerChaseGenerator(
+	maxAddress, numNodes uint64,
+	rng *rand.Rand,
+) (*PointerChaseGenerator, error) {
+	if numNodes == 0 {
+		return nil, errors.New("pointer chase needs at least one node")
+	}
+
+	if maxAddress/numNodes < 4 {
+		return nil, fmt.Errorf("address space of %d bytes cannot hold %d "+
+			"nodes", maxAddress, numNodes)
+	}
+
+	// Sattolo's algorithm produces a permutation with a single cycle.
+	nextNode := make([]uint32, numNodes)
+	for i := range nextNode {
+		nextNode[i] = uint32(i)
+	}
+	for i := len(nextNode) - 1; i > 0; i-- {
+		j := rng.Intn(i)
+		nextNode[i], nextNode[j] = nextNode[j], nextNode[i]
+	}
+
+	return &PointerChaseGenerator{
+		nextNode: nextNode,
+		nodeSize: alignAddress(maxAddress / numNodes),
+	}, nil
+}
+
+// NextAddress returns the address of the next node in the chain.
+func (g *PointerChaseGenerator) NextAddress(_ bool) uint64 {
+	addr := uint64(g.current) * g.nodeSize
+	g.current = g.nextNode[g.current]
+
+	return addr
+}
+
+// CoalescedGenerator mimics the accesses of GPU compute units. Each compute
+// unit owns a slice of the address space and accesses it sequentially, one
+// cache line at a time, as a wavefront with contiguous lanes would. The compute
+// units take turns issuing their lines.
+type CoalescedGenerator struct {
+	numCUs      uint64
+	regionSize  uint64
+	offsets     []uint64
+	currentCU   uint64
+	accessInCU  uint64
+	accessPerCU uint64
+}
+
+// NewCoalescedGenerator creates a CoalescedGenerator for numCUs compute units
+// that access lines of lineSize bytes below maxAddress.
+func NewCoalescedGenerator(
+	maxAddress, numCUs, lineSize uint64,
+) (*CoalescedGenerator, error) {
+	if numCUs == 0 {
+		return nil, errors.New("coalesced accesses need at least one CU")
+	}
+
+	if lineSize < 4 || lineSize%4 != 0 {
+		return nil, fmt.Errorf("line size %d is not a multiple of 4", lineSize)
+	}
+
+	regionSize := maxAddress / numCUs / lineSize * lineSize
+	if regionSize == 0 {
+		return nil, fmt.Errorf("address space of %d bytes cannot hold a "+
+			"line of %d bytes for each of %d CUs",
+			maxAddress, lineSize, numCUs)
+	}
+
+	return &CoalescedGenerator{
+		numCUs:      numCUs,
+		regionSize:  regionSize,
+		offsets:     make([]uint64, numCUs),
+		accessPerCU: lineSize / 4,
+	}, nil
+}
+
+// NextAddress returns the address accessed by the next lane.
+func (g *CoalescedGenerator) NextAddress(_ bool) uint64 {
+	cu := g.currentCU
+	addr := cu*g.regionSize + g.offsets[cu]
+
+	g.offsets[cu] = (g.offsets[cu] + 4) % g.regionSize
+
+	g.accessInCU++
+	if g.accessInCU == g.accessPerCU {
+		g.accessInCU = 0
+		g.currentCU = (g.currentCU + 1) % g.numCUs
+	}
+
+	return addr
+}
+
+// NewSharingGenerator creates an address generator for one of several agents
+// that share a memory of maxAddress bytes. The supported modes are "private"
+// (each agent accesses its own range), "shared" (all the agents access the
+// same range), "mixed" (mostly private, with a quarter of the accesses to a
+// shared range), and "false_sharing" (the agents access their own words in
+// the same cache lines). The random choices are drawn from rng. It returns an
+// error if the agent index is not one of the numAgents agents.
+func NewSharingGenerator(
+	mode string,
+	agentIndex, numAgents int,
+	maxAddress uint64,
+	rng *rand.Rand,
+) (AddressGenerator, error) {
+	if numAgents <= 0 || agentIndex < 0 || agentIndex >= numAgents {
+		return nil, fmt.Errorf("agent index %d is out of the %d agents",
+			agentIndex, numAgents)
+	}
+
+	if mode == "false_sharing" {
+		return NewFalseSharingGenerator(
+			0, maxAddress/64, 64, agentIndex, numAgents, rng)
+	}
+
+	regionSize := alignAddress(maxAddress / uint64(numAgents+1))
+
+	private, err := NewWorkingSetGenerator(
+		uint64(agentIndex)*regionSize, regionSize, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := NewWorkingSetGenerator(
+		uint64(numAgents)*regionSize, regionSize, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case "private":
+		return private, nil
+	case "shared":
+		return shared, nil
+	case "mixed":
+		return NewMixedRangeGenerator(private, shared, 0.25, rng), nil
+	default:
+		return nil, fmt.Errorf("unknown sharing mode %q", mode)
+	}
+}
+
+// MixedRangeGenerator sends a fraction of the accesses to a shared range and
+// the others to a private range, each described by its own generator.
+type MixedRangeGenerator struct {
+	private     AddressGenerator
+	shared      AddressGenerator
+	sharedRatio float64
+	rng         *rand.Rand
+}
+
+// NewMixedRangeGenerator creates a MixedRangeGenerator that sends the
+// sharedRatio fraction of the accesses to the shared generator.
+func NewMixedRangeGenerator(
+	private, shared AddressGenerator,
+	sharedRatio float64,
+	rng *rand.Rand,
+) *MixedRangeGenerator {
+	return &MixedRangeGenerator{
+		private:     private,
+		shared:      shared,
+		sharedRatio: sharedRatio,
+		rng:         rng,
+	}
+}
+
+// NextAddress returns an address from the private or the shared range.
+func (g *MixedRangeGenerator) NextAddress(read bool) uint64 {
+	if g.rng.Float64() < g.sharedRatio {
+		return g.shared.NextAddress(read)
+	}
+
+	return g.private.NextAddress(read)
+}
+
+// FalseSharingGenerator divides each cache line among the agents, so that
+// every agent accesses only its own part of lines that all the agents use.
+type FalseSharingGenerator struct {
+	base      uint64
+	numLines  uint64
+	lineSize  uint64
+	slotBase  uint64
+	slotWords uint64
+	rng       *rand.Rand
+}
+
+// NewFalseSharingGenerator creates a FalseSharingGenerator for the agent with
+// the given index, accessing numLines lines of lineSize bytes from base. Each
+// agent needs at least a word of each line. The random choices are drawn from
+// rng.
+func NewFalseSharingGenerator(
+	base, numLines, lineSize uint64,
+	agentIndex, numAgents int,
+	rng *rand.Rand,
+) (*FalseSharingGenerator, error) {
+	if numAgents <= 0 || agentIndex < 0 || agentIndex >= numAgents {
+		return nil, fmt.Errorf("agent index %d is out of the %d agents",
+			agentIndex, numAgents)
+	}
+
+	if numLines == 0 {
+		return nil, errors.New("false sharing needs at least one line")
+	}
+
+	slotSize := alignAddress(lineSize / uint64(numAgents))
+	if slotSize < 4 {
+		return nil, fmt.Errorf("lines of %d bytes cannot hold a word for "+
+			"each of the %d agents", lineSize, numAgents)
+	}
+
+	return &FalseSharingGenerator{
+		base:      alignAddress(base),
+		numLines:  numLines,
+		lineSize:  lineSize,
+		slotBase:  uint64(agentIndex) * slotSize,
+		slotWords: slotSize / 4,
+		rng:       rng,
+	}, nil
+}
+
+// NextAddress returns a random word of the part of a random line that the
+// agent owns.
+func (g *FalseSharingGenerator) NextAddress(_ bool) uint64 {
+	line := g.rng.Uint64() % g.numLines
+	word := g.rng.Uint64() % g.slotWords
+
+	return g.base + line*g.lineSize + g.slotBase + word*4
+}
@@ -0,0 +1,51 @@
+package memaccessagent
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSharingGeneratorAgents(t *testing.T) {
+	tests := []struct {
+		name       string
+		agentIndex int
+		numAgents  int
+		wantErr    bool
+	}{
+		{name: "first agent", agentIndex: 0, numAgents: 4},
+		{name: "last agent", agentIndex: 3, numAgents: 4},
+		{name: "no agent", agentIndex: 0, numAgents: 0, wantErr: true},
+		{name: "index too large", agentIndex: 4, numAgents: 4, wantErr: true},
+		{name: "negative index", agentIndex: -1, numAgents: 4, wantErr: true},
+	}
+
+	modes := []string{"private", "shared", "mixed", "false_sharing"}
+
+	for _, test := range tests {
+		for _, mode := range modes {
+			t.Run(test.name+"/"+mode, func(t *testing.T) {
+				rng := rand.New(rand.NewSource(1))
+
+				g, err := NewSharingGenerator(
+					mode, test.agentIndex, test.numAgents, 1<<20, rng)
+				if test.wantErr {
+					if err == nil {
+						t.Fatal("got no error")
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for i := 0; i < 100; i++ {
+					if addr := g.NextAddress(i%2 == 0); addr+4 > 1<<20 {
+						t.Fatalf("address 0x%X is out of the memory", addr)
+					}
+				}
+			})
+		}
+	}
+}
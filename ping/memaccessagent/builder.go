@@ -2,19 +2,64 @@
 package memaccessagent
 
 import (
-	"github.com/sarchlab/akita/v4/sim"
+	"fmt"
 	"math/rand"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 type Builder struct {
-	name       string
-	engine     sim.Engine
-	freq       sim.Freq
-	maxAddress uint64
-	writeLeft  int
-	readLeft   int
+	name              string
+	engine            sim.Engine
+	freq              sim.Freq
+	maxAddress        uint64
+	writeLeft         int
+	readLeft          int
 	useVirtualAddress bool
-	lowModule  sim.Port
+	verifyRead        bool
+	readRatio         float64
+	pattern           string
+	generator         AddressGenerator
+	sizes             string
+	sizeGen           SizeGenerator
+	misaligned        bool
+	crossRate         float64
+	boundary          uint64
+	trace             TraceReader
+	traceTime         bool
+	lowModule         sim.Port
+
+	maxOutstanding  int
+	issueInterval   int
+	targetBandwidth float64
+	closedLoop      bool
+	thinkTime       int
+
+	recordRequestTimes bool
+	occupancyWindow    int
+
+	warmup          int
+	stopTime        sim.VTimeInSec
+	steadyWindow    int
+	steadyTolerance float64
+
+	controlTargets []sim.Port
+	flushRate      float64
+	invalidateRate float64
+	pauseRate      float64
+	pauseCycles    int
+
+	pageTable    vm.PageTable
+	log2PageSize uint64
+	virtualBase  uint64
+	physicalBase uint64
+	placement    string
+
+	memory *GoldenMemory
+	seed   int64
 }
 
 func MakeBuilder() *Builder {
@@ -24,6 +69,14 @@ func MakeBuilder() *Builder {
 		maxAddress: 1024 * 1024,
 		writeLeft:  1000,
 		readLeft:   1000,
+		verifyRead: true,
+		readRatio:  0.5,
+
+		occupancyWindow: defaultOccupancyWindow,
+
+		log2PageSize: 12,
+		virtualBase:  defaultVirtualBase,
+		seed:         1,
 	}
 }
 
@@ -62,8 +115,198 @@ func (b *Builder) UseVirtualAddress(use bool) *Builder {
 	return b
 }
 
-func (a *MemAccessAgent) randomVirtualAddress() uint64 {
-	return rand.Uint64() % (a.MaxAddress / 4) * 4
+// WithPageTable sets the page table that the agent registers its pages in
+// when it uses virtual addresses.
+func (b *Builder) WithPageTable(pageTable vm.PageTable) *Builder {
+	b.pageTable = pageTable
+	return b
+}
+
+// WithLog2PageSize sets the page size used to map the virtual address range.
+func (b *Builder) WithLog2PageSize(n uint64) *Builder {
+	b.log2PageSize = n
+	return b
+}
+
+// WithVirtualBase sets the first virtual address that the agent accesses.
+func (b *Builder) WithVirtualBase(addr uint64) *Builder {
+	b.virtualBase = addr
+	return b
+}
+
+// WithPhysicalPlacement sets where the virtual pages are placed in the
+// physical memory. The pages take the physical frames starting at base,
+// either in the "contiguous" or in "random" order.
+func (b *Builder) WithPhysicalPlacement(base uint64, placement string) *Builder {
+	b.physicalBase = base
+	b.placement = placement
+	return b
+}
+
+// WithSeed sets the seed of the random choices of the agent, so that runs are
+// reproducible.
+func (b *Builder) WithSeed(seed int64) *Builder {
+	b.seed = seed
+	return b
+}
+
+// WithGoldenMemory sets the golden memory that the agent records its writes in
+// and checks its reads against. Agents that access the same memory should
+// share a golden memory.
+func (b *Builder) WithGoldenMemory(m *GoldenMemory) *Builder {
+	b.memory = m
+	return b
+}
+
+// WithReadVerification sets whether the agent checks the data returned by
+// reads against the values it has written.
+func (b *Builder) WithReadVerification(verify bool) *Builder {
+	b.verifyRead = verify
+	return b
+}
+
+// WithReadRatio sets the probability that an access is a read.
+func (b *Builder) WithReadRatio(ratio float64) *Builder {
+	b.readRatio = ratio
+	return b
+}
+
+// WithAddressPattern selects one of the built-in address generators by name.
+// See NewAddressGenerator for the supported patterns.
+func (b *Builder) WithAddressPattern(pattern string) *Builder {
+	b.pattern = pattern
+	return b
+}
+
+// WithAddressGenerator sets the generator that decides the addresses to
+// access. It overrides the address pattern.
+func (b *Builder) WithAddressGenerator(g AddressGenerator) *Builder {
+	b.generator = g
+	return b
+}
+
+// WithSizeDistribution selects one of the built-in size generators by name.
+// See NewSizeGenerator for the supported distributions.
+func (b *Builder) WithSizeDistribution(distribution string) *Builder {
+	b.sizes = distribution
+	return b
+}
+
+// WithSizeGenerator sets the generator that decides the size of each access.
+// It overrides the size distribution.
+func (b *Builder) WithSizeGenerator(g SizeGenerator) *Builder {
+	b.sizeGen = g
+	return b
+}
+
+// WithMisalignment sets whether accesses can start at any byte.
+func (b *Builder) WithMisalignment(misaligned bool) *Builder {
+	b.misaligned = misaligned
+	return b
+}
+
+// WithBoundaryCrossing makes a fraction of the accesses span a boundary of
+// boundarySize bytes, such as a cache line or a page.
+func (b *Builder) WithBoundaryCrossing(rate float64, boundarySize uint64) *Builder {
+	b.crossRate = rate
+	b.boundary = boundarySize
+	return b
+}
+
+// WithTrace makes the agent replay the accesses of a trace. If respectTime is
+// true, each access is issued at the time recorded in the trace. Otherwise,
+// the accesses are issued as fast as possible.
+func (b *Builder) WithTrace(trace TraceReader, respectTime bool) *Builder {
+	b.trace = trace
+	b.traceTime = respectTime
+	return b
+}
+
+// WithMaxOutstanding limits the number of requests in flight. Zero means no
+// limit.
+func (b *Builder) WithMaxOutstanding(n int) *Builder {
+	b.maxOutstanding = n
+	return b
+}
+
+// WithIssueInterval sets the minimum number of cycles between two requests.
+func (b *Builder) WithIssueInterval(cycles int) *Builder {
+	b.issueInterval = cycles
+	return b
+}
+
+// WithTargetBandwidth limits the issue rate to the given number of bytes per
+// second.
+func (b *Builder) WithTargetBandwidth(bytesPerSecond float64) *Builder {
+	b.targetBandwidth = bytesPerSecond
+	return b
+}
+
+// WithClosedLoop makes each request slot wait for thinkTime cycles after a
+// response before issuing the next request. The number of slots is set with
+// WithMaxOutstanding.
+func (b *Builder) WithClosedLoop(thinkTime int) *Builder {
+	b.closedLoop = true
+	b.thinkTime = thinkTime
+	return b
+}
+
+// WithRequestTimes sets whether the agent keeps the issue and completion time
+// of every request.
+func (b *Builder) WithRequestTimes(record bool) *Builder {
+	b.recordRequestTimes = record
+	return b
+}
+
+// WithOccupancyWindow sets the number of cycles covered by each sample of the
+// number of requests in flight. Zero disables the sampling.
+func (b *Builder) WithOccupancyWindow(cycles int) *Builder {
+	b.occupancyWindow = cycles
+	return b
+}
+
+// WithWarmup excludes the first n requests from the statistics.
+func (b *Builder) WithWarmup(n int) *Builder {
+	b.warmup = n
+	return b
+}
+
+// WithStopTime makes the agent stop issuing requests at the given time.
+func (b *Builder) WithStopTime(t sim.VTimeInSec) *Builder {
+	b.stopTime = t
+	return b
+}
+
+// WithSteadyState makes the agent stop issuing requests once the average
+// latencies of the last few windows of window requests differ by at most
+// tolerance, relative to the lowest one.
+func (b *Builder) WithSteadyState(window int, tolerance float64) *Builder {
+	b.steadyWindow = window
+	b.steadyTolerance = tolerance
+	return b
+}
+
+// WithControlTargets sets the control ports of the caches that the maintenance
+// operations are sent to. The operations are sent to the caches in order, so
+// the caches closer to the agent should come first.
+func (b *Builder) WithControlTargets(ports ...sim.Port) *Builder {
+	b.controlTargets = ports
+	return b
+}
+
+// WithMaintenanceRates sets the probabilities that the agent flushes,
+// invalidates or pauses the caches before an access.
+func (b *Builder) WithMaintenanceRates(flush, invalidate, pause float64) *Builder {
+	b.flushRate = flush
+	b.invalidateRate = invalidate
+	b.pauseRate = pause
+	return b
+}
+
+// WithPauseCycles sets the number of cycles that the caches stay paused.
+func (b *Builder) WithPauseCycles(cycles int) *Builder {
+	b.pauseCycles = cycles
+	return b
 }
 
 func (b *Builder) WithLowModule(port sim.Port) *Builder {
@@ -80,11 +323,84 @@ func (b *Builder) Build(name string) *MemAccessAgent {
 	agent.ReadLeft = b.readLeft
 
 	agent.UseVirtualAddress = b.useVirtualAddress
+	agent.PageTable = b.pageTable
+	agent.Log2PageSize = b.log2PageSize
+	agent.VirtualBase = b.virtualBase
+	agent.PhysicalBase = b.physicalBase
+	agent.PagePlacement = b.placement
+	agent.VerifyRead = b.verifyRead
+	agent.ReadRatio = b.readRatio
+	agent.Rand = rand.New(rand.NewSource(b.seed))
+
+	if b.memory != nil {
+		agent.Memory = b.memory
+	}
+
+	agent.Trace = b.trace
+	agent.RespectTraceTime = b.traceTime
+
+	agent.AddressGenerator = b.generator
+	if agent.AddressGenerator == nil && b.pattern != "" {
+		g, err := NewAddressGenerator(b.pattern, b.maxAddress, agent.Rand)
+		if err != nil {
+			panic(err)
+		}
+
+		agent.AddressGenerator = g
+	}
+
+	agent.SizeGenerator = b.sizeGen
+	if agent.SizeGenerator == nil && b.sizes != "" {
+		g, err := NewSizeGenerator(b.sizes, agent.Rand)
+		if err != nil {
+			panic(err)
+		}
+
+		agent.SizeGenerator = g
+	}
+
+	maxSize := uint64(4)
+	if g, ok := agent.SizeGenerator.(sizeLimiter); ok {
+		maxSize = g.MaxSize()
+	}
+
+	if agent.Trace == nil && maxSize > b.maxAddress {
+		panic(fmt.Sprintf("access size %d is larger than the max address %d",
+			maxSize, b.maxAddress))
+	}
+
+	agent.MaxOutstanding = b.maxOutstanding
+	agent.IssueInterval = b.issueInterval
+	agent.TargetBandwidth = b.targetBandwidth
+	agent.ClosedLoop = b.closedLoop
+	agent.ThinkTime = b.thinkTime
+
+	agent.RecordRequestTimes = b.recordRequestTimes
+	agent.OccupancyWindow = b.occupancyWindow
+	agent.Warmup = b.warmup
+	agent.StopTime = b.stopTime
+	agent.SteadyStateWindow = b.steadyWindow
+	agent.SteadyStateTolerance = b.steadyTolerance
+
+	agent.ControlTargets = b.controlTargets
+	agent.FlushRate = b.flushRate
+	agent.InvalidateRate = b.invalidateRate
+	agent.PauseRate = b.pauseRate
+	agent.PauseCycles = b.pauseCycles
+	agent.initMaintenanceLatency()
+	agent.ReadLatency = metrics_reporter.NewLatencyHistogram()
+	agent.WriteLatency = metrics_reporter.NewLatencyHistogram()
+
+	agent.Misaligned = b.misaligned
+	agent.CrossBoundaryRate = b.crossRate
+	agent.BoundarySize = b.boundary
 
 	agent.memPort = sim.NewPort(agent, 1, 1, name+".Mem")
 	agent.AddPort("Mem", agent.memPort)
+	agent.controlPort = sim.NewPort(agent, 1, 1, name+".Control")
+	agent.AddPort("Control", agent.controlPort)
 
-	if b.lowModule != nil {               
+	if b.lowModule != nil {
 		agent.LowModule = b.lowModule
 	}
 
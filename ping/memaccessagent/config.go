@@ -0,0 +1,180 @@
+package memaccessagent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// A Config describes the accesses that a benchmark makes an agent issue. The
+// benchmarks find their agent in a simulation that is already built, so the
+// configuration is applied to the agent rather than passed to its builder.
+type Config struct {
+	// NumAccess is the number of reads and the number of writes to issue,
+	// within the first MaxAddress bytes. ReadRatio is the probability that an
+	// access is a read.
+	NumAccess  int
+	MaxAddress uint64
+	ReadRatio  float64
+
+	// AddressPattern and SizeDistribution select the built-in address and
+	// size generators. See NewAddressGenerator and NewSizeGenerator. Empty
+	// values keep the uniformly random 4-byte accesses.
+	AddressPattern   string
+	SizeDistribution string
+
+	Misaligned        bool
+	CrossBoundaryRate float64
+	BoundarySize      uint64
+
+	// MaxOutstanding limits the number of requests in flight, and
+	// IssueInterval and TargetBandwidth limit the issue rate. In the closed
+	// loop mode, each request slot waits ThinkTime cycles after a response.
+	MaxOutstanding  int
+	IssueInterval   int
+	TargetBandwidth float64
+	ClosedLoop      bool
+	ThinkTime       int
+
+	// TraceFile, if set, is a trace that the agent replays instead of
+	// generating accesses, at the recorded times if TraceTiming is set.
+	// RecordFile, if set, is where the issued accesses are recorded.
+	TraceFile   string
+	TraceTiming bool
+	RecordFile  string
+
+	// The agent excludes the first Warmup requests from the statistics. It
+	// stops issuing requests at the time limit, after CycleLimit cycles, or
+	// once the latency of windows of SteadyWindow requests is stable within
+	// SteadyTolerance.
+	Warmup          int
+	TimeLimit       sim.VTimeInSec
+	CycleLimit      uint64
+	SteadyWindow    int
+	SteadyTolerance float64
+
+	FlushRate      float64
+	InvalidateRate float64
+	PauseRate      float64
+	PauseCycles    int
+}
+
+// DefaultConfig returns a configuration with as many reads as writes and no
+// other setting. The address range must still be set.
+func DefaultConfig() Config {
+	return Config{
+		ReadRatio: 0.5,
+	}
+}
+
+// ApplyTo configures an agent. The generators draw from the random source of
+// the agent. If a trace file is set, it is opened and replayed instead of the
+// generated accesses; the agent must be closed to close the file. It returns
+// an error if the address range is empty or the number of accesses is
+// negative.
+func (c Config) ApplyTo(agent *MemAccessAgent) error {
+	if c.MaxAddress == 0 {
+		return errors.New("the max address of the agent is not set")
+	}
+
+	if c.NumAccess < 0 {
+		return fmt.Errorf("number of accesses %d is negative", c.NumAccess)
+	}
+
+	agent.WriteLeft = c.NumAccess
+	agent.ReadLeft = c.NumAccess
+	agent.MaxAddress = c.MaxAddress
+	agent.ReadRatio = c.ReadRatio
+
+	if c.AddressPattern != "" {
+		g, err := NewAddressGenerator(c.AddressPattern, c.MaxAddress, agent.Rand)
+		if err != nil {
+			return err
+		}
+
+		agent.AddressGenerator = g
+	}
+
+	if c.SizeDistribution != "" {
+		g, err := NewSizeGenerator(c.SizeDistribution, agent.Rand)
+		if err != nil {
+			return err
+		}
+
+		if l, ok := g.(sizeLimiter); ok && l.MaxSize() > c.MaxAddress {
+			return fmt.Errorf("size distribution %q does not fit in %d bytes",
+				c.SizeDistribution, c.MaxAddress)
+		}
+
+		agent.SizeGenerator = g
+	}
+
+	agent.Misaligned = c.Misaligned
+	agent.CrossBoundaryRate = c.CrossBoundaryRate
+	agent.BoundarySize = c.BoundarySize
+
+	agent.MaxOutstanding = c.MaxOutstanding
+	agent.IssueInterval = c.IssueInterval
+	agent.TargetBandwidth = c.TargetBandwidth
+	agent.ClosedLoop = c.ClosedLoop
+	agent.ThinkTime = c.ThinkTime
+
+	agent.Warmup = c.Warmup
+	agent.StopTime = c.StopTime(agent.Freq)
+	agent.SteadyStateWindow = c.SteadyWindow
+	agent.SteadyStateTolerance = c.SteadyTolerance
+
+	agent.FlushRate = c.FlushRate
+	agent.InvalidateRate = c.InvalidateRate
+	agent.PauseRate = c.PauseRate
+	agent.PauseCycles = c.PauseCycles
+
+	if c.TraceFile != "" {
+		trace, err := OpenTrace(c.TraceFile)
+		if err != nil {
+			return err
+		}
+
+		agent.Trace = trace
+		agent.RespectTraceTime = c.TraceTiming
+		agent.WriteLeft = 0
+		agent.ReadLeft = 0
+	}
+
+	return nil
+}
+
+// StopTime returns the earlier of the time limit and the cycle limit, for an
+// agent running at freq, or 0 if there is no limit.
+func (c Config) StopTime(freq sim.Freq) sim.VTimeInSec {
+	t := c.TimeLimit
+
+	if c.CycleLimit > 0 {
+		cycleTime := sim.VTimeInSec(c.CycleLimit) * freq.Period()
+		if t == 0 || cycleTime < t {
+			t = cycleTime
+		}
+	}
+
+	return t
+}
+
+// Record starts recording the accesses of the agent into the record file, if
+// one is set. It returns nil if there is nothing to record.
+func (c Config) Record(
+	agent *MemAccessAgent,
+	timeTeller sim.TimeTeller,
+) (*TraceRecorder, error) {
+	if c.RecordFile == "" {
+		return nil, nil
+	}
+
+	recorder, err := RecordPortTrace(
+		agent.GetPortByName("Mem"), timeTeller, c.RecordFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot record the trace: %w", err)
+	}
+
+	return recorder, nil
+}
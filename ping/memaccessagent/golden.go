@@ -0,0 +1,156 @@
+package memaccessagent
+
+// maxKnownValues bounds the number of values that the golden memory remembers
+// for each byte. Older values are compacted away.
+const maxKnownValues = 8
+
+// goldenWordSize is the number of bytes that the golden memory groups under
+// a single entry.
+const goldenWordSize = 8
+
+// A GoldenMemory is the reference copy of the memory content that agents
+// check their reads against. Agents that share a GoldenMemory can access the
+// same memory and validate each other's writes. The golden memory is indexed
+// by memory addresses, that is, the physical addresses of the agents that use
+// virtual addresses, so that processes that share pages also share values.
+type GoldenMemory struct {
+	// words holds the history of the bytes written so far, grouped by aligned
+	// word.
+	words map[uint64]*goldenWord
+}
+
+// A goldenWord holds the history of each byte of an aligned word.
+type goldenWord [goldenWordSize]goldenByte
+
+// A goldenByte holds the most recent values written to a byte, in the order
+// that the writes were issued.
+type goldenByte struct {
+	values        []byte
+	compacted     int
+	pendingWrites int
+}
+
+// NewGoldenMemory creates an empty GoldenMemory.
+func NewGoldenMemory() *GoldenMemory {
+	return &GoldenMemory{
+		words: make(map[uint64]*goldenWord),
+	}
+}
+
+// byteAt returns the history of a byte, or nil if the byte has never been
+// written.
+func (m *GoldenMemory) byteAt(addr uint64) *goldenByte {
+	word := m.words[addr/goldenWordSize]
+	if word == nil {
+		return nil
+	}
+
+	return &word[addr%goldenWordSize]
+}
+
+// writeByte records a value written to a byte by a write that has just been
+// issued.
+func (m *GoldenMemory) writeByte(addr uint64, v byte) {
+	word := m.words[addr/goldenWordSize]
+	if word == nil {
+		word = new(goldenWord)
+		m.words[addr/goldenWordSize] = word
+	}
+
+	b := &word[addr%goldenWordSize]
+	b.values = append(b.values, v)
+	b.pendingWrites++
+	b.compact()
+}
+
+// completeByte records that a write to a byte has completed.
+func (m *GoldenMemory) completeByte(addr uint64) {
+	if b := m.byteAt(addr); b != nil {
+		b.pendingWrites--
+	}
+}
+
+// historyIndex returns the number of values that have ever been written to
+// a byte, including the compacted ones, so that the index of a value stays
+// valid after compaction.
+func (m *GoldenMemory) historyIndex(addr uint64) int {
+	b := m.byteAt(addr)
+	if b == nil {
+		return 0
+	}
+
+	return b.compacted + len(b.values)
+}
+
+// firstLegalValue returns the index of the earliest value that a read issued
+// now may return: the value of the last completed write. Every later value is
+// also legal. A negative index means that no write to the byte has completed,
+// so the initial content of the memory is also legal.
+func (m *GoldenMemory) firstLegalValue(addr uint64) int {
+	b := m.byteAt(addr)
+	if b == nil {
+		return -1
+	}
+
+	return m.historyIndex(addr) - 1 - b.pendingWrites
+}
+
+// legalValues returns the values that a read may return, given the index of
+// the first legal value.
+func (m *GoldenMemory) legalValues(addr uint64, firstLegalValue int) []byte {
+	values := make([]byte, 0)
+
+	if firstLegalValue < 0 {
+		values = append(values, initialMemValue)
+	}
+
+	b := m.byteAt(addr)
+	if b == nil {
+		return values
+	}
+
+	first := max(firstLegalValue-b.compacted, 0)
+
+	return append(values, b.values[first:]...)
+}
+
+// isStale tells if a value is one that the byte held before the first legal
+// value, which means that the read returned an outdated copy.
+func (m *GoldenMemory) isStale(addr uint64, firstLegalValue int, v byte) bool {
+	if firstLegalValue < 0 {
+		return false
+	}
+
+	if v == initialMemValue {
+		return true
+	}
+
+	b := m.byteAt(addr)
+	if b == nil {
+		return false
+	}
+
+	last := min(max(firstLegalValue-b.compacted, 0), len(b.values))
+
+	for _, old := range b.values[:last] {
+		if old == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compact keeps the most recent maxKnownValues values of a byte once twice as
+// many have accumulated. Reads only need the values written while they are in
+// flight, so the compaction only matters for bytes written many times during
+// a single read.
+func (b *goldenByte) compact() {
+	if len(b.values) <= 2*maxKnownValues {
+		return
+	}
+
+	drop := len(b.values) - maxKnownValues
+	b.values = append(b.values[:0:0], b.values[drop:]...)
+	b.compacted += drop
+}
@@ -0,0 +1,189 @@
+package memaccessagent
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGoldenMemoryLegalValues(t *testing.T) {
+	const addr = 0x43
+
+	tests := []struct {
+		name      string
+		writes    []byte
+		completed int
+		want      []byte
+		stale     []byte
+		fresh     []byte
+	}{
+		{
+			name:  "never written",
+			want:  []byte{initialMemValue},
+			fresh: []byte{initialMemValue},
+		},
+		{
+			name:   "write in flight",
+			writes: []byte{1},
+			want:   []byte{initialMemValue, 1},
+			fresh:  []byte{initialMemValue, 1},
+		},
+		{
+			name:      "write completed",
+			writes:    []byte{1},
+			completed: 1,
+			want:      []byte{1},
+			stale:     []byte{initialMemValue},
+			fresh:     []byte{1},
+		},
+		{
+			name:      "later writes in flight",
+			writes:    []byte{1, 2, 3},
+			completed: 1,
+			want:      []byte{1, 2, 3},
+			stale:     []byte{initialMemValue},
+			fresh:     []byte{1, 2, 3},
+		},
+		{
+			name:      "all writes completed",
+			writes:    []byte{1, 2, 3},
+			completed: 3,
+			want:      []byte{3},
+			stale:     []byte{initialMemValue, 1, 2},
+			fresh:     []byte{3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewGoldenMemory()
+
+			for _, v := range test.writes {
+				m.writeByte(addr, v)
+			}
+
+			for i := 0; i < test.completed; i++ {
+				m.completeByte(addr)
+			}
+
+			first := m.firstLegalValue(addr)
+
+			got := m.legalValues(addr, first)
+			if !bytes.Equal(got, test.want) {
+				t.Errorf("legal values: got %v, want %v", got, test.want)
+			}
+
+			for _, v := range test.stale {
+				if !m.isStale(addr, first, v) {
+					t.Errorf("%d is not stale", v)
+				}
+			}
+
+			for _, v := range test.fresh {
+				if m.isStale(addr, first, v) {
+					t.Errorf("%d is stale", v)
+				}
+			}
+
+			other := m.legalValues(addr+1, m.firstLegalValue(addr+1))
+			if !bytes.Equal(other, []byte{initialMemValue}) {
+				t.Errorf("neighbor byte: got %v, want only the initial value",
+					other)
+			}
+		})
+	}
+}
+
+func TestGoldenMemoryCompaction(t *testing.T) {
+	const addr = 0x1000
+
+	tests := []struct {
+		name          string
+		numWrites     int
+		wantCompacted int
+		wantKept      int
+	}{
+		{
+			name:      "below the limit",
+			numWrites: 2 * maxKnownValues,
+			wantKept:  2 * maxKnownValues,
+		},
+		{
+			name:          "over the limit",
+			numWrites:     2*maxKnownValues + 1,
+			wantCompacted: maxKnownValues + 1,
+			wantKept:      maxKnownValues,
+		},
+		{
+			name:          "compacted twice",
+			numWrites:     3*maxKnownValues + 2,
+			wantCompacted: 2*maxKnownValues + 2,
+			wantKept:      maxKnownValues,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewGoldenMemory()
+
+			// The read is issued after the first write completed, and all
+			// the other writes are issued while the read is in flight.
+			m.writeByte(addr, 0)
+			m.completeByte(addr)
+			first := m.firstLegalValue(addr)
+
+			for i := 1; i < test.numWrites; i++ {
+				m.writeByte(addr, byte(i))
+				m.completeByte(addr)
+			}
+
+			b := m.byteAt(addr)
+			if b.compacted != test.wantCompacted || len(b.values) != test.wantKept {
+				t.Errorf("got %d compacted and %d kept values, want %d and %d",
+					b.compacted, len(b.values),
+					test.wantCompacted, test.wantKept)
+			}
+
+			if got := m.historyIndex(addr); got != test.numWrites {
+				t.Errorf("history index: got %d, want %d", got, test.numWrites)
+			}
+
+			last := byte(test.numWrites - 1)
+			now := m.legalValues(addr, m.firstLegalValue(addr))
+			if !bytes.Equal(now, []byte{last}) {
+				t.Errorf("legal values now: got %v, want [%d]", now, last)
+			}
+
+			// The values that the in-flight read may return are the ones
+			// that are still known.
+			got := m.legalValues(addr, first)
+			if len(got) != test.wantKept || got[len(got)-1] != last {
+				t.Errorf("legal values of the read: got %v", got)
+			}
+
+			if m.isStale(addr, first, last) {
+				t.Errorf("the last value is stale for the read")
+			}
+		})
+	}
+}
+
+func TestGoldenMemoryGroupsBytesByWord(t *testing.T) {
+	m := NewGoldenMemory()
+
+	for addr := uint64(0); addr < 2*goldenWordSize; addr++ {
+		m.writeByte(addr, byte(addr))
+	}
+
+	if len(m.words) != 2 {
+		t.Errorf("got %d words, want 2", len(m.words))
+	}
+
+	for addr := uint64(0); addr < 2*goldenWordSize; addr++ {
+		got := m.legalValues(addr, m.firstLegalValue(addr))
+		want := []byte{initialMemValue, byte(addr)}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("address %d: got %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package memaccessagent
+
+// maxIndexedAddresses bounds the number of written addresses that the agent
+// remembers for picking read addresses. Once the index is full, it keeps a
+// uniform sample of all the written addresses.
+const maxIndexedAddresses = 1 << 20
+
+// addPendingRange counts a pending request on each byte that it covers.
+func addPendingRange(pending map[uint64]int, addr, size uint64) {
+	for b := addr; b < addr+size; b++ {
+		pending[b]++
+	}
+}
+
+// removePendingRange undoes addPendingRange.
+func removePendingRange(pending map[uint64]int, addr, size uint64) {
+	for b := addr; b < addr+size; b++ {
+		pending[b]--
+		if pending[b] == 0 {
+			delete(pending, b)
+		}
+	}
+}
+
+func isRangePending(pending map[uint64]int, addr, size uint64) bool {
+	if len(pending) == 0 {
+		return false
+	}
+
+	for b := addr; b < addr+size; b++ {
+		if pending[b] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// indexWrittenAddress adds the start address of a write to the index of
+// written addresses, using reservoir sampling once the index is full. The
+// addresses in the index are tracked by slot, so that an address is not
+// indexed twice and is forgotten when its slot is taken by another address.
+func (a *MemAccessAgent) indexWrittenAddress(addr uint64) {
+	if _, found := a.addressSlots[addr]; found {
+		return
+	}
+
+	a.numWrittenAddresses++
+
+	if len(a.writtenAddresses) < maxIndexedAddresses {
+		a.addressSlots[addr] = len(a.writtenAddresses)
+		a.writtenAddresses = append(a.writtenAddresses, addr)
+
+		return
+	}
+
+	slot := a.Rand.Intn(a.numWrittenAddresses)
+	if slot < maxIndexedAddresses {
+		delete(a.addressSlots, a.writtenAddresses[slot])
+		a.writtenAddresses[slot] = addr
+		a.addressSlots[addr] = slot
+	}
+}
+
+// randomReadAddress returns a random address that the agent has written.
+func (a *MemAccessAgent) randomReadAddress() uint64 {
+	return a.writtenAddresses[a.Rand.Intn(len(a.writtenAddresses))]
+}
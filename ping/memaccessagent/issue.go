@@ -0,0 +1,175 @@
+package memaccessagent
+
+import (
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// canIssue checks the outstanding request window, and makes sure the agent
+// wakes up when a think time ends.
+func (a *MemAccessAgent) canIssue() bool {
+	now := a.CurrentTime()
+
+	for len(a.thinking) > 0 && a.thinking[0] <= now {
+		a.thinking = a.thinking[1:]
+	}
+
+	window := a.window()
+	if window == 0 {
+		return true
+	}
+
+	busy := len(a.PendingReadReq) + len(a.PendingWriteReq) + len(a.thinking)
+	if busy < window {
+		return true
+	}
+
+	// Responses wake the agent up through the port. Only the end of a think
+	// time needs an explicit wake up.
+	if len(a.thinking) > 0 {
+		a.wakeUpAt(a.thinking[0])
+	}
+
+	return false
+}
+
+// window returns the maximum number of requests in flight, or 0 if there is
+// no limit.
+func (a *MemAccessAgent) window() int {
+	if a.ClosedLoop && a.MaxOutstanding == 0 {
+		return 1
+	}
+
+	return a.MaxOutstanding
+}
+
+// recordIssue updates the issue rate control and the latency statistics when
+// a request is sent.
+func (a *MemAccessAgent) recordIssue(
+	id string,
+	read bool,
+	address, size uint64,
+) {
+	now := a.CurrentTime()
+
+	if a.NumIssued == 0 {
+		a.firstIssueTime = now
+		a.windowStart = now
+		a.lastOccupancyChange = now
+	}
+
+	a.updateOccupancy(now)
+	if a.Warmup > 0 && a.NumIssued == a.Warmup {
+		a.startMeasurement(now)
+	}
+
+	a.outstanding++
+	a.PeakOutstanding = max(a.PeakOutstanding, a.outstanding)
+
+	a.inflight[id] = RequestTiming{
+		ID:        id,
+		Read:      read,
+		Address:   address,
+		Size:      size,
+		IssueTime: now,
+		warmup:    a.NumIssued < a.Warmup,
+	}
+	a.NumIssued++
+
+	delay := sim.VTimeInSec(a.IssueInterval) * a.Freq.Period()
+	if a.TargetBandwidth > 0 {
+		delay = max(delay, sim.VTimeInSec(float64(size)/a.TargetBandwidth))
+	}
+
+	if delay > 0 {
+		a.nextIssueTime = a.Freq.ThisTick(now + delay)
+	}
+}
+
+// recordCompletion updates the latency statistics and, in the closed-loop
+// mode, starts the think time when a response arrives.
+func (a *MemAccessAgent) recordCompletion(id string) {
+	now := a.CurrentTime()
+
+	timing, found := a.inflight[id]
+	if found {
+		delete(a.inflight, id)
+
+		a.updateOccupancy(now)
+		a.outstanding--
+	}
+
+	if found && !timing.warmup {
+		a.windowBytes += timing.Size
+
+		timing.CompleteTime = now
+		if timing.Read {
+			a.ReadLatency.Add(timing.Latency())
+			a.BytesRead += timing.Size
+		} else {
+			a.WriteLatency.Add(timing.Latency())
+			a.BytesWritten += timing.Size
+		}
+
+		if a.RecordRequestTimes {
+			a.RequestTimes = append(a.RequestTimes, timing)
+		}
+
+		a.NumCompleted++
+		a.TotalLatency += timing.Latency()
+		a.BytesCompleted += timing.Size
+		a.lastCompleteTime = now
+
+		a.checkSteadyState(timing.Latency())
+	}
+
+	if a.ClosedLoop {
+		thinkTime := sim.VTimeInSec(a.ThinkTime) * a.Freq.Period()
+		a.thinking = append(a.thinking, a.Freq.ThisTick(now+thinkTime))
+	}
+}
+
+// AverageLatency returns the average time from sending a request to receiving
+// its response.
+func (a *MemAccessAgent) AverageLatency() sim.VTimeInSec {
+	if a.NumCompleted == 0 {
+		return 0
+	}
+
+	return a.TotalLatency / sim.VTimeInSec(a.NumCompleted)
+}
+
+// Bandwidth returns the number of bytes accessed per second, from the first
+// request to the last response.
+func (a *MemAccessAgent) Bandwidth() float64 {
+	return a.bandwidthOf(a.BytesCompleted)
+}
+
+// A wakeUpEvent makes an agent that waits for a given time tick at that time.
+type wakeUpEvent struct {
+	*sim.EventBase
+}
+
+// wakeUpAt makes the agent tick at the given time, even if nothing else
+// happens before then. The agent does not tick while it waits, and at most
+// one wake up is scheduled for each time.
+func (a *MemAccessAgent) wakeUpAt(t sim.VTimeInSec) {
+	if t <= a.CurrentTime() || a.wakeUps[t] {
+		return
+	}
+
+	a.wakeUps[t] = true
+	a.Engine.Schedule(wakeUpEvent{EventBase: sim.NewEventBase(t, a)})
+}
+
+// Handle ticks the agent when it wakes up. The wake up goes through TickNow,
+// so the agent never ticks twice in a cycle.
+func (a *MemAccessAgent) Handle(e sim.Event) error {
+	if _, ok := e.(wakeUpEvent); ok {
+		delete(a.wakeUps, e.Time())
+		a.TickNow()
+
+		return nil
+	}
+
+	return a.TickingComponent.Handle(e)
+}
@@ -0,0 +1,102 @@
+package memaccessagent
+
+import (
+	"testing"
+
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+)
+
+// tickCounter counts the tick events of a component.
+type tickCounter struct {
+	handler sim.Handler
+	ticks   int
+}
+
+func (c *tickCounter) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosBeforeEvent {
+		return
+	}
+
+	e, ok := ctx.Item.(sim.TickEvent)
+	if ok && e.Handler() == c.handler {
+		c.ticks++
+	}
+}
+
+func TestWaitingAgentDoesNotTick(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *Builder) *Builder
+	}{
+		{
+			name: "issue interval",
+			build: func(b *Builder) *Builder {
+				return b.WithIssueInterval(1000)
+			},
+		},
+		{
+			name: "think time",
+			build: func(b *Builder) *Builder {
+				return b.WithClosedLoop(1000)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := sim.NewSerialEngine()
+
+			memCtrl := idealmemcontroller.MakeBuilder().
+				WithEngine(engine).
+				WithNewStorage(1 * mem.MB).
+				WithLatency(10).
+				Build("MemCtrl")
+
+			agent := test.build(MakeBuilder().
+				WithEngine(engine).
+				WithMaxAddress(4096).
+				WithWriteLeft(5).
+				WithReadLeft(5).
+				WithRequestTimes(true).
+				WithLowModule(memCtrl.GetPortByName("Top"))).
+				Build("Agent")
+
+			conn := directconnection.MakeBuilder().
+				WithEngine(engine).
+				WithFreq(1 * sim.GHz).
+				Build("Conn")
+			conn.PlugIn(agent.GetPortByName("Mem"))
+			conn.PlugIn(memCtrl.GetPortByName("Top"))
+
+			counter := &tickCounter{handler: agent.TickingComponent}
+			engine.AcceptHook(counter)
+
+			agent.TickLater()
+
+			if err := engine.Run(); err != nil {
+				t.Fatalf("simulation failed: %v", err)
+			}
+
+			if agent.NumCompleted != 10 {
+				t.Fatalf("got %d completed requests, want 10", agent.NumCompleted)
+			}
+
+			requests := agent.RequestTimes
+			for i := 1; i < len(requests); i++ {
+				gap := requests[i].IssueTime - requests[i-1].IssueTime
+				if gap < 999*sim.GHz.Period() {
+					t.Errorf("request %d is issued %.2e s after the previous one",
+						i, gap)
+				}
+			}
+
+			if counter.ticks > 100 {
+				t.Errorf("got %d ticks, want the agent to sleep while waiting",
+					counter.ticks)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package memaccessagent
+
+import (
+	"log"
+	"reflect"
+
+	"github.com/sarchlab/akita/v4/mem/cache"
+	"github.com/sarchlab/akita/v4/sim"
+
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+)
+
+// A MaintenanceKind is a cache maintenance operation.
+type MaintenanceKind int
+
+// The agent can flush the caches, invalidate them, or pause them for a while.
+// Akita caches always write the dirty lines back, so an invalidation keeps the
+// data correct too.
+const (
+	NoMaintenance MaintenanceKind = iota
+	MaintenanceFlush
+	MaintenanceInvalidate
+	MaintenancePause
+)
+
+// String returns the name of the operation.
+func (k MaintenanceKind) String() string {
+	switch k {
+	case NoMaintenance:
+		return "none"
+	case MaintenanceFlush:
+		return "flush"
+	case MaintenanceInvalidate:
+		return "invalidate"
+	case MaintenancePause:
+		return "pause"
+	default:
+		return "unknown"
+	}
+}
+
+type maintenancePhase int
+
+const (
+	maintenanceDraining maintenancePhase = iota
+	maintenanceSending
+	maintenanceWaiting
+	maintenancePaused
+)
+
+// maintenanceOp is a maintenance operation that is sent to the caches one
+// after another. A pause ends with a restart of all the caches.
+type maintenanceOp struct {
+	kind        MaintenanceKind
+	pauseCycles int
+	phase       maintenancePhase
+	restarting  bool
+	target      int
+	startTime   sim.VTimeInSec
+	resumeTime  sim.VTimeInSec
+}
+
+func (a *MemAccessAgent) initMaintenanceLatency() {
+	a.FlushLatency = metrics_reporter.NewLatencyHistogram()
+	a.InvalidateLatency = metrics_reporter.NewLatencyHistogram()
+	a.PauseLatency = metrics_reporter.NewLatencyHistogram()
+	a.RestartLatency = metrics_reporter.NewLatencyHistogram()
+}
+
+// randomMaintenance decides if a maintenance operation is performed before
+// the next access.
+func (a *MemAccessAgent) randomMaintenance() MaintenanceKind {
+	if len(a.ControlTargets) == 0 {
+		return NoMaintenance
+	}
+
+	r := a.Rand.Float64()
+	switch {
+	case r < a.FlushRate:
+		return MaintenanceFlush
+	case r < a.FlushRate+a.InvalidateRate:
+		return MaintenanceInvalidate
+	case r < a.FlushRate+a.InvalidateRate+a.PauseRate:
+		return MaintenancePause
+	default:
+		return NoMaintenance
+	}
+}
+
+// startMaintenance stops issuing accesses until the operation completes. The
+// operation is sent once the requests in flight complete, since the caches
+// drop the requests that arrive while they flush. It does nothing if there
+// are no caches to control.
+func (a *MemAccessAgent) startMaintenance(kind MaintenanceKind, pauseCycles int) {
+	if kind == NoMaintenance || len(a.ControlTargets) == 0 {
+		return
+	}
+
+	a.maintenance = &maintenanceOp{
+		kind:        kind,
+		pauseCycles: pauseCycles,
+		phase:       maintenanceDraining,
+	}
+}
+
+// doMaintenance makes progress on the current maintenance operation.
+func (a *MemAccessAgent) doMaintenance() bool {
+	op := a.maintenance
+	now := a.CurrentTime()
+
+	switch op.phase {
+	case maintenanceDraining:
+		// Responses wake the agent up through the port.
+		if len(a.PendingReadReq) > 0 || len(a.PendingWriteReq) > 0 {
+			return false
+		}
+
+		op.phase = maintenanceSending
+		op.startTime = now
+	case maintenancePaused:
+		if now < op.resumeTime {
+			a.wakeUpAt(op.resumeTime)
+			return false
+		}
+
+		op.phase = maintenanceSending
+		op.restarting = true
+		op.target = 0
+		op.startTime = now
+	}
+
+	if op.phase != maintenanceSending {
+		return false
+	}
+
+	return a.sendControlReq()
+}
+
+func (a *MemAccessAgent) sendControlReq() bool {
+	op := a.maintenance
+	dst := a.ControlTargets[op.target].AsRemote()
+
+	var req sim.Msg
+	if op.restarting {
+		req = cache.RestartReqBuilder{}.
+			WithSrc(a.controlPort.AsRemote()).
+			WithDst(dst).
+			Build()
+	} else {
+		builder := cache.FlushReqBuilder{}.
+			WithSrc(a.controlPort.AsRemote()).
+			WithDst(dst)
+
+		switch op.kind {
+		case MaintenanceInvalidate:
+			builder = builder.InvalidateAllCacheLines()
+		case MaintenancePause:
+			builder = builder.PauseAfterFlushing()
+		}
+
+		req = builder.Build()
+	}
+
+	if a.controlPort.Send(req) != nil {
+		return false
+	}
+
+	op.phase = maintenanceWaiting
+
+	return true
+}
+
+// processControlRsp moves the maintenance operation to the next cache when a
+// cache responds. Some caches do not set the ID of the request that they
+// respond to, so the responses are matched by the order of the caches.
+func (a *MemAccessAgent) processControlRsp() bool {
+	msg := a.controlPort.RetrieveIncoming()
+	if msg == nil {
+		return false
+	}
+
+	switch msg.(type) {
+	case *cache.FlushRsp, *cache.RestartRsp:
+	default:
+		log.Panicf("cannot process message of type %s", reflect.TypeOf(msg))
+	}
+
+	op := a.maintenance
+	if op == nil || op.phase != maintenanceWaiting {
+		log.Panicf("unexpected control response of type %s",
+			reflect.TypeOf(msg))
+	}
+
+	op.target++
+	if op.target < len(a.ControlTargets) {
+		op.phase = maintenanceSending
+		return true
+	}
+
+	a.completeMaintenancePhase()
+
+	return true
+}
+
+// completeMaintenancePhase records the latency of an operation once all the
+// caches have responded. A pause then waits before restarting the caches.
+func (a *MemAccessAgent) completeMaintenancePhase() {
+	op := a.maintenance
+	now := a.CurrentTime()
+	latency := now - op.startTime
+
+	switch {
+	case op.restarting:
+		a.RestartLatency.Add(latency)
+	case op.kind == MaintenanceFlush:
+		a.FlushLatency.Add(latency)
+	case op.kind == MaintenanceInvalidate:
+		a.InvalidateLatency.Add(latency)
+	case op.kind == MaintenancePause:
+		a.PauseLatency.Add(latency)
+
+		pause := sim.VTimeInSec(op.pauseCycles) * a.Freq.Period()
+		op.phase = maintenancePaused
+		op.resumeTime = a.Freq.ThisTick(now + pause)
+		a.wakeUpAt(op.resumeTime)
+
+		return
+	}
+
+	a.maintenance = nil
+}
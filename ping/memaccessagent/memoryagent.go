@@ -3,17 +3,29 @@
 package memaccessagent
 
 import (
-	"encoding/binary"
+	"fmt"
 	"log"
+	"math/bits"
 	"math/rand"
 	"reflect"
 
 	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
+
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
 )
 
 var dumpLog = false
 
+// plannedAccess is an access that the agent has decided to issue, but has not
+// been able to send yet.
+type plannedAccess struct {
+	read    bool
+	address uint64
+	size    uint64
+}
+
 // A MemAccessAgent is a Component that can help testing the cache and the the
 // memory controllers by generating a large number of read and write requests.
 type MemAccessAgent struct {
@@ -24,31 +36,247 @@ type MemAccessAgent struct {
 
 	WriteLeft       int
 	ReadLeft        int
-	KnownMemValue   map[uint64][]uint32
 	PendingReadReq  map[string]*mem.ReadReq
 	PendingWriteReq map[string]*mem.WriteReq
 
-	memPort           sim.Port
-	UseVirtualAddress bool
+	// Memory holds the values written to the memory. Agents that share the
+	// same memory can share a GoldenMemory to check each other's writes.
+	Memory *GoldenMemory
+
+	// The pending byte counts index the pending requests by address, and the
+	// written addresses sample the addresses to read from.
+	pendingReadBytes    map[uint64]int
+	pendingWriteBytes   map[uint64]int
+	writtenAddresses    []uint64
+	numWrittenAddresses int
+	addressSlots        map[uint64]int
+
+	memPort     sim.Port
+	controlPort sim.Port
+
+	// ControlTargets are the control ports of the caches that the maintenance
+	// operations are sent to, in order. Before each access, the agent flushes
+	// the caches with the probability FlushRate, invalidates them with the
+	// probability InvalidateRate, or pauses them for PauseCycles cycles with
+	// the probability PauseRate.
+	ControlTargets    []sim.Port
+	FlushRate         float64
+	InvalidateRate    float64
+	PauseRate         float64
+	PauseCycles       int
+	FlushLatency      *metrics_reporter.LatencyHistogram
+	InvalidateLatency *metrics_reporter.LatencyHistogram
+	PauseLatency      *metrics_reporter.LatencyHistogram
+	RestartLatency    *metrics_reporter.LatencyHistogram
+	maintenance       *maintenanceOp
+
+	// PID is the process that the accesses belong to.
+	PID vm.PID
+
+	// Rand is the source of the random choices of the agent and of its
+	// built-in generators. Runs with the same seed issue the same accesses.
+	Rand *rand.Rand
+
+	// In the virtual address mode, the agent accesses the MaxAddress bytes
+	// starting at VirtualBase. Before the first access, it maps them in
+	// PageTable to physical pages of 1<<Log2PageSize bytes starting at
+	// PhysicalBase, either "contiguous" or in "random" order.
+	UseVirtualAddress     bool
+	VirtualBase           uint64
+	PageTable             vm.PageTable
+	Log2PageSize          uint64
+	PhysicalBase          uint64
+	PagePlacement         string
+	DeviceID              uint64
+	TranslationMismatches []TranslationMismatch
+	pagesMapped           bool
+	frameToPage           map[uint64]uint64
+
+	// AddressGenerator decides the addresses to access. If it is nil, the
+	// agent writes to random addresses and reads from random addresses that
+	// it has written.
+	AddressGenerator AddressGenerator
+
+	// ReadRatio is the probability that an access is a read.
+	ReadRatio float64
+
+	// SizeGenerator decides the number of bytes of each access. If it is nil,
+	// all the accesses are 4 bytes.
+	SizeGenerator SizeGenerator
+
+	// Misaligned allows accesses to start at any byte. Otherwise, accesses
+	// are aligned to the largest power of two that is not larger than their
+	// size.
+	Misaligned bool
+
+	// CrossBoundaryRate is the probability that an access is moved so that it
+	// spans a boundary of BoundarySize bytes, such as a cache line or a page.
+	CrossBoundaryRate float64
+	BoundarySize      uint64
+
+	plannedAccess *plannedAccess
+
+	// VerifyRead enables checking the data returned by each read against the
+	// values that the agent has written. Failed checks are collected in
+	// ReadMismatches.
+	VerifyRead     bool
+	ReadMismatches []ReadMismatch
+	readChecks     map[string]*readCheck
+
+	// Trace, if set, provides the accesses to issue instead of the randomly
+	// generated ones. If RespectTraceTime is true, each access is issued at
+	// the time recorded in the trace. Otherwise, accesses are issued as fast
+	// as possible.
+	Trace            TraceReader
+	RespectTraceTime bool
+	NumReplayed      int
+	replay           traceReplay
+	err              error
+
+	// MaxOutstanding limits the number of requests in flight. Zero means no
+	// limit.
+	MaxOutstanding int
+
+	// IssueInterval is the minimum number of cycles between two requests.
+	// TargetBandwidth, in bytes per second, further spaces the requests
+	// according to their sizes. Zero means no limit.
+	IssueInterval   int
+	TargetBandwidth float64
+	nextIssueTime   sim.VTimeInSec
+
+	// ClosedLoop makes each of the MaxOutstanding request slots wait for
+	// ThinkTime cycles after a response before issuing the next request.
+	ClosedLoop bool
+	ThinkTime  int
+	thinking   []sim.VTimeInSec
+
+	// wakeUps holds the times at which a wake up is scheduled.
+	wakeUps map[sim.VTimeInSec]bool
+
+	NumIssued        int
+	NumCompleted     int
+	TotalLatency     sim.VTimeInSec
+	BytesCompleted   uint64
+	BytesRead        uint64
+	BytesWritten     uint64
+	ReadLatency      *metrics_reporter.LatencyHistogram
+	WriteLatency     *metrics_reporter.LatencyHistogram
+	inflight         map[string]RequestTiming
+	firstIssueTime   sim.VTimeInSec
+	lastCompleteTime sim.VTimeInSec
+
+	// RecordRequestTimes makes the agent keep the timing of every completed
+	// request in RequestTimes.
+	RecordRequestTimes bool
+	RequestTimes       []RequestTiming
+
+	// Occupancy samples the number of requests in flight and the completed
+	// bytes every OccupancyWindow cycles. Zero disables the sampling.
+	OccupancyWindow     int
+	Occupancy           []OccupancySample
+	PeakOutstanding     int
+	outstanding         int
+	occupancyArea       float64
+	lastOccupancyChange sim.VTimeInSec
+	windowStart         sim.VTimeInSec
+	windowArea          float64
+	windowBytes         uint64
+
+	// Warmup is the number of requests, at the start of the run, that are
+	// excluded from the statistics.
+	Warmup int
+
+	// StopTime, if not zero, makes the agent stop issuing requests at that
+	// time. SteadyStateWindow, if not zero, makes the agent stop once the
+	// average latencies of the last few windows of SteadyStateWindow requests
+	// differ by at most SteadyStateTolerance, relative to the lowest one.
+	// StopReason tells why the agent has stopped early.
+	StopTime             sim.VTimeInSec
+	SteadyStateWindow    int
+	SteadyStateTolerance float64
+	StopReason           string
+	steadyWindowLatency  sim.VTimeInSec
+	steadyWindowCount    int
+	steadyMeans          []sim.VTimeInSec
 }
 
 // Tick updates the states of the agent and issues new read and write requests.
 func (a *MemAccessAgent) Tick() bool {
 	madeProgress := false
 
+	a.mapPages()
+	a.checkStopTime()
+
 	madeProgress = a.processMsgRsp() || madeProgress
+	madeProgress = a.processControlRsp() || madeProgress
+
+	if a.maintenance != nil {
+		return a.doMaintenance() || madeProgress
+	}
+
+	if !a.hasWorkLeft() {
+		return madeProgress
+	}
+
+	if a.nextIssueTime > a.CurrentTime() {
+		a.wakeUpAt(a.nextIssueTime)
+		return madeProgress
+	}
 
-	if a.ReadLeft == 0 && a.WriteLeft == 0 {
+	if !a.canIssue() {
 		return madeProgress
 	}
 
-	if a.shouldRead() {
-		madeProgress = a.doRead() || madeProgress
+	if a.Trace != nil {
+		return a.replayTrace() || madeProgress
+	}
+
+	if a.plannedAccess == nil {
+		if kind := a.randomMaintenance(); kind != NoMaintenance {
+			a.startMaintenance(kind, a.PauseCycles)
+			return true
+		}
+
+		read := a.shouldRead()
+		size := a.nextSize(read)
+		if size == 0 {
+			a.err = fmt.Errorf("%s: %w", a.Name(), errZeroSize)
+			a.stop("size error")
+
+			return madeProgress
+		}
+
+		a.plannedAccess = &plannedAccess{
+			read:    read,
+			address: a.placeAccess(a.nextAddress(read), size),
+			size:    size,
+		}
+	}
+
+	issued := false
+	if a.plannedAccess.read {
+		issued = a.doRead(a.plannedAccess.address, a.plannedAccess.size)
 	} else {
-		madeProgress = a.doWrite() || madeProgress
+		issued = a.doWrite(a.plannedAccess.address, a.plannedAccess.size)
+	}
+
+	if issued {
+		a.plannedAccess = nil
+	}
+
+	return issued || madeProgress
+}
+
+func (a *MemAccessAgent) hasWorkLeft() bool {
+	if a.Stopped() {
+		return false
 	}
 
-	return madeProgress
+	if a.Trace != nil {
+		return !a.replay.done
+	}
+
+	return a.ReadLeft > 0 || a.WriteLeft > 0
 }
 
 func (a *MemAccessAgent) processMsgRsp() bool {
@@ -65,11 +293,17 @@ func (a *MemAccessAgent) processMsgRsp() bool {
 				a.CurrentTime(), write.Address)
 		}
 
+		write := a.PendingWriteReq[msg.RespondTo]
+		size := uint64(len(write.Data))
+		a.recordCompletion(msg.RespondTo)
+		a.recordWriteDone(write)
+		removePendingRange(a.pendingWriteBytes, write.Address, size)
 		delete(a.PendingWriteReq, msg.RespondTo)
 
 		return true
 	case *mem.DataReadyRsp:
 		req := a.PendingReadReq[msg.RespondTo]
+		removePendingRange(a.pendingReadBytes, req.Address, req.AccessByteSize)
 		delete(a.PendingReadReq, msg.RespondTo)
 
 		if dumpLog {
@@ -77,7 +311,8 @@ func (a *MemAccessAgent) processMsgRsp() bool {
 				a.CurrentTime(), req.Address, msg.Data)
 		}
 
-		// a.checkReadResult(req, msg)
+		a.checkReadResult(req, msg)
+		a.recordCompletion(msg.RespondTo)
 
 		return true
 	default:
@@ -88,7 +323,7 @@ func (a *MemAccessAgent) processMsgRsp() bool {
 }
 
 func (a *MemAccessAgent) shouldRead() bool {
-	if len(a.KnownMemValue) == 0 {
+	if len(a.writtenAddresses) == 0 {
 		return false
 	}
 
@@ -100,20 +335,73 @@ func (a *MemAccessAgent) shouldRead() bool {
 		return true
 	}
 
-	dice := rand.Float64()
+	dice := a.Rand.Float64()
 
-	return dice > 0.5
+	return dice < a.ReadRatio
 }
 
-func (a *MemAccessAgent) doRead() bool {
-	var address uint64
-	if a.UseVirtualAddress {
-		address = a.randomVirtualAddress()
-	} else {
-		address = a.randomReadAddress()
+func (a *MemAccessAgent) nextAddress(read bool) uint64 {
+	if a.AddressGenerator != nil {
+		return a.addressBase() + a.AddressGenerator.NextAddress(read)
+	}
+
+	if read {
+		return a.randomReadAddress()
+	}
+
+	return a.addressBase() + a.Rand.Uint64()%(a.MaxAddress/4)*4
+}
+
+func (a *MemAccessAgent) nextSize(read bool) uint64 {
+	if a.SizeGenerator == nil {
+		return 4
+	}
+
+	return a.SizeGenerator.NextSize(read)
+}
+
+// placeAccess adjusts the address of an access according to the alignment and
+// boundary crossing settings, keeping the access within the address range.
+func (a *MemAccessAgent) placeAccess(address, size uint64) uint64 {
+	switch {
+	case size > 1 && a.BoundarySize > 0 && a.Rand.Float64() < a.CrossBoundaryRate:
+		bytesBefore := 1 + a.Rand.Uint64()%(size-1)
+		boundary := (address/a.BoundarySize + 1) * a.BoundarySize
+		for boundary < bytesBefore {
+			boundary += a.BoundarySize
+		}
+
+		address = boundary - bytesBefore
+	case a.Misaligned:
+		address += a.Rand.Uint64() % size
+	default:
+		alignment := uint64(1) << (bits.Len64(size) - 1)
+		address = address / alignment * alignment
+	}
+
+	limit := a.addressBase() + a.MaxAddress
+	switch {
+	case size > a.MaxAddress:
+		address = a.addressBase()
+	case address+size > limit:
+		address = limit - size
+	}
+
+	return address
+}
+
+func (a *MemAccessAgent) doRead(address, size uint64) bool {
+	if !a.sendRead(address, size, a.PID) {
+		return false
 	}
 
-	if a.isAddressInPendingReq(address) {
+	a.ReadLeft--
+
+	return true
+}
+
+func (a *MemAccessAgent) sendRead(address, size uint64, pid vm.PID) bool {
+	if a.isRangeInPendingReq(address, size) {
 		return false
 	}
 
@@ -121,14 +409,17 @@ func (a *MemAccessAgent) doRead() bool {
 		WithSrc(a.memPort.AsRemote()).
 		WithDst(a.LowModule.AsRemote()).
 		WithAddress(address).
-		WithByteSize(4).
-		WithPID(1).
+		WithByteSize(size).
+		WithPID(pid).
+		WithInfo(a.memPort.AsRemote()).
 		Build()
 
 	err := a.memPort.Send(readReq)
 	if err == nil {
+		a.recordReadCheck(readReq)
+		a.recordIssue(readReq.ID, true, address, size)
 		a.PendingReadReq[readReq.ID] = readReq
-		a.ReadLeft--
+		addPendingRange(a.pendingReadBytes, address, size)
 
 		if dumpLog {
 			log.Printf("%.10f, agent, read, 0x%X\n", a.CurrentTime(), address)
@@ -140,62 +431,26 @@ func (a *MemAccessAgent) doRead() bool {
 	return false
 }
 
-func (a *MemAccessAgent) randomReadAddress() uint64 {
-	var addr uint64
-
-	for {
-		if a.UseVirtualAddress {
-			addr = 0x100000000 + rand.Uint64()%(a.MaxAddress/4)*4 // e.g., start virtual at 0x100000000
-		} else {
-			addr = rand.Uint64() % (a.MaxAddress / 4) * 4
-		}
-		if _, written := a.KnownMemValue[addr]; written {
-			return addr
-		}
-	}
-}
-
-func (a *MemAccessAgent) isAddressInPendingReq(addr uint64) bool {
-	return a.isAddressInPendingWrite(addr) || a.isAddressInPendingRead(addr)
+func (a *MemAccessAgent) isRangeInPendingReq(addr, size uint64) bool {
+	return isRangePending(a.pendingWriteBytes, addr, size) ||
+		isRangePending(a.pendingReadBytes, addr, size)
 }
 
-func (a *MemAccessAgent) isAddressInPendingWrite(addr uint64) bool {
-	for _, write := range a.PendingWriteReq {
-		if write.Address == addr {
-			return true
-		}
-	}
-
-	return false
-}
+func (a *MemAccessAgent) doWrite(address, size uint64) bool {
+	data := make([]byte, size)
+	a.Rand.Read(data)
 
-func (a *MemAccessAgent) isAddressInPendingRead(addr uint64) bool {
-	for _, read := range a.PendingReadReq {
-		if read.Address == addr {
-			return true
-		}
+	if !a.sendWrite(address, data, a.PID) {
+		return false
 	}
 
-	return false
-}
+	a.WriteLeft--
 
-func uint32ToBytes(data uint32) []byte {
-	bytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bytes, data)
-
-	return bytes
+	return true
 }
 
-func (a *MemAccessAgent) doWrite() bool {
-	var address uint64
-	if a.UseVirtualAddress {
-		address = rand.Uint64() % (a.MaxAddress / 4) * 4
-	} else {
-		address = rand.Uint64() % (a.MaxAddress / 4) * 4
-	}
-	data := rand.Uint32()
-
-	if a.isAddressInPendingReq(address) {
+func (a *MemAccessAgent) sendWrite(address uint64, data []byte, pid vm.PID) bool {
+	if a.isRangeInPendingReq(address, uint64(len(data))) {
 		return false
 	}
 
@@ -203,15 +458,17 @@ func (a *MemAccessAgent) doWrite() bool {
 		WithSrc(a.memPort.AsRemote()).
 		WithDst(a.LowModule.AsRemote()).
 		WithAddress(address).
-		WithPID(1).
-		WithData(uint32ToBytes(data)).
+		WithPID(pid).
+		WithData(data).
+		WithInfo(a.memPort.AsRemote()).
 		Build()
 
 	err := a.memPort.Send(writeReq)
 	if err == nil {
-		a.WriteLeft--
-		a.addKnownValue(address, data)
+		a.recordWrittenData(address, data, pid)
+		a.recordIssue(writeReq.ID, false, address, uint64(len(data)))
 		a.PendingWriteReq[writeReq.ID] = writeReq
+		addPendingRange(a.pendingWriteBytes, address, uint64(len(data)))
 
 		if dumpLog {
 			log.Printf("%.10f, agent, write, 0x%X, %v\n",
@@ -224,15 +481,27 @@ func (a *MemAccessAgent) doWrite() bool {
 	return false
 }
 
-func (a *MemAccessAgent) addKnownValue(address uint64, data uint32) {
-	valueList, exist := a.KnownMemValue[address]
-	if !exist {
-		valueList = make([]uint32, 0)
-		a.KnownMemValue[address] = valueList
+// recordWrittenData remembers the data written to each byte so that later
+// reads can be verified.
+func (a *MemAccessAgent) recordWrittenData(
+	address uint64,
+	data []byte,
+	pid vm.PID,
+) {
+	a.indexWrittenAddress(address)
+
+	memAddrs := a.memoryAddresses(address, uint64(len(data)), pid)
+	for i, b := range data {
+		a.Memory.writeByte(memAddrs[i], b)
 	}
+}
 
-	valueList = append(valueList, data)
-	a.KnownMemValue[address] = valueList
+func (a *MemAccessAgent) recordWriteDone(write *mem.WriteReq) {
+	memAddrs := a.memoryAddresses(
+		write.Address, uint64(len(write.Data)), write.PID)
+	for _, addr := range memAddrs {
+		a.Memory.completeByte(addr)
+	}
 }
 
 // NewMemAccessAgent creates a new MemAccessAgent.
@@ -243,12 +512,31 @@ func NewMemAccessAgent(engine sim.Engine) *MemAccessAgent {
 
 	agent.memPort = sim.NewPort(agent, 1, 1, "Agent.MemPort")
 	agent.AddPort("Mem", agent.memPort)
-
+	agent.controlPort = sim.NewPort(agent, 1, 1, "Agent.ControlPort")
+	agent.AddPort("Control", agent.controlPort)
+
+	agent.PID = 1
+	agent.Rand = rand.New(rand.NewSource(1))
+	agent.VirtualBase = defaultVirtualBase
+	agent.Log2PageSize = 12
+	agent.DeviceID = 1
 	agent.ReadLeft = 10000
 	agent.WriteLeft = 10000
-	agent.KnownMemValue = make(map[uint64][]uint32)
+	agent.Memory = NewGoldenMemory()
 	agent.PendingWriteReq = make(map[string]*mem.WriteReq)
 	agent.PendingReadReq = make(map[string]*mem.ReadReq)
+	agent.pendingReadBytes = make(map[uint64]int)
+	agent.pendingWriteBytes = make(map[uint64]int)
+	agent.addressSlots = make(map[uint64]int)
+	agent.VerifyRead = true
+	agent.ReadRatio = 0.5
+	agent.readChecks = make(map[string]*readCheck)
+	agent.inflight = make(map[string]RequestTiming)
+	agent.wakeUps = make(map[sim.VTimeInSec]bool)
+	agent.ReadLatency = metrics_reporter.NewLatencyHistogram()
+	agent.WriteLatency = metrics_reporter.NewLatencyHistogram()
+	agent.OccupancyWindow = defaultOccupancyWindow
+	agent.initMaintenanceLatency()
 
 	return agent
 }
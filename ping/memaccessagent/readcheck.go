@@ -0,0 +1,191 @@
+package memaccessagent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// A ReadMismatch describes a read that returned data that cannot be
+// explained by the writes that the agents have issued. It details the first
+// wrong byte of the read. A read is stale if all its wrong bytes hold values
+// that were overwritten before the read was issued, as an incoherent cache
+// would return.
+type ReadMismatch struct {
+	ReqID         string
+	Agent         string
+	Address       uint64
+	Size          uint64
+	Offset        uint64
+	Expected      []byte
+	Got           byte
+	NumWrongBytes int
+	Stale         bool
+	IssueTime     sim.VTimeInSec
+	Time          sim.VTimeInSec
+	Path          []string
+}
+
+// String returns a human-readable report of the mismatch.
+func (m ReadMismatch) String() string {
+	expected := make([]string, 0, len(m.Expected))
+	for _, v := range m.Expected {
+		expected = append(expected, fmt.Sprintf("0x%02X", v))
+	}
+
+	kind := "read mismatch"
+	if m.Stale {
+		kind = "stale read"
+	}
+
+	return fmt.Sprintf(
+		"%s: agent %s, req %s, address 0x%X, size %d, %d wrong bytes, "+
+			"byte 0x%X expected one of [%s], got 0x%02X, "+
+			"issued at %.10f, returned at %.10f, path %s",
+		kind, m.Agent, m.ReqID, m.Address, m.Size, m.NumWrongBytes,
+		m.Address+m.Offset, strings.Join(expected, ", "), m.Got,
+		m.IssueTime, m.Time, strings.Join(m.Path, " -> "))
+}
+
+// readCheck remembers what a read may legally return.
+type readCheck struct {
+	issueTime sim.VTimeInSec
+
+	// memAddrs are the golden memory addresses of the bytes of the read.
+	memAddrs []uint64
+
+	// firstLegalValue holds, for each byte of the read, the index into the
+	// golden memory history of the earliest value that the byte may return.
+	// See GoldenMemory.firstLegalValue.
+	firstLegalValue []int
+}
+
+// initialMemValue is the content of the memory before any write.
+const initialMemValue = byte(0)
+
+func (a *MemAccessAgent) recordReadCheck(req *mem.ReadReq) {
+	if !a.VerifyRead {
+		return
+	}
+
+	check := &readCheck{
+		issueTime:       a.CurrentTime(),
+		memAddrs:        a.memoryAddresses(req.Address, req.AccessByteSize, req.PID),
+		firstLegalValue: make([]int, req.AccessByteSize),
+	}
+
+	for i, addr := range check.memAddrs {
+		check.firstLegalValue[i] = a.Memory.firstLegalValue(addr)
+	}
+
+	a.readChecks[req.ID] = check
+}
+
+func (a *MemAccessAgent) checkReadResult(
+	req *mem.ReadReq,
+	rsp *mem.DataReadyRsp,
+) {
+	check, found := a.readChecks[req.ID]
+	if !found {
+		return
+	}
+
+	delete(a.readChecks, req.ID)
+
+	var mismatch *ReadMismatch
+	for i, got := range rsp.Data {
+		addr := check.memAddrs[i]
+		first := check.firstLegalValue[i]
+		expected := a.Memory.legalValues(addr, first)
+
+		if slices.Contains(expected, got) {
+			continue
+		}
+
+		if mismatch == nil {
+			mismatch = &ReadMismatch{
+				ReqID:     req.ID,
+				Agent:     a.Name(),
+				Stale:     true,
+				Address:   req.Address,
+				Size:      req.AccessByteSize,
+				Offset:    uint64(i),
+				Expected:  expected,
+				Got:       got,
+				IssueTime: check.issueTime,
+				Time:      a.CurrentTime(),
+				Path:      []string{string(req.Src), string(req.Dst)},
+			}
+
+			if rsp.Src != req.Dst {
+				mismatch.Path = append(mismatch.Path, string(rsp.Src))
+			}
+		}
+
+		mismatch.NumWrongBytes++
+		mismatch.Stale = mismatch.Stale && a.Memory.isStale(addr, first, got)
+	}
+
+	if mismatch != nil {
+		a.ReadMismatches = append(a.ReadMismatches, *mismatch)
+	}
+}
+
+// maxReportedMismatches limits the number of mismatches detailed in the error
+// returned by VerificationError.
+const maxReportedMismatches = 10
+
+// NumStaleReads returns the number of reads that returned stale data.
+func (a *MemAccessAgent) NumStaleReads() int {
+	n := 0
+	for _, m := range a.ReadMismatches {
+		if m.Stale {
+			n++
+		}
+	}
+
+	return n
+}
+
+// VerificationError returns an error that details the reads that returned
+// wrong data and the requests that were translated wrongly, or nil if all
+// the accesses are correct.
+func (a *MemAccessAgent) VerificationError() error {
+	if len(a.ReadMismatches) == 0 && len(a.TranslationMismatches) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+
+	if len(a.ReadMismatches) > 0 {
+		fmt.Fprintf(&sb, "%d reads returned wrong data", len(a.ReadMismatches))
+		writeMismatches(&sb, a.ReadMismatches)
+	}
+
+	if len(a.TranslationMismatches) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		fmt.Fprintf(&sb, "%d requests were translated wrongly",
+			len(a.TranslationMismatches))
+		writeMismatches(&sb, a.TranslationMismatches)
+	}
+
+	return fmt.Errorf("%s", sb.String())
+}
+
+func writeMismatches[T fmt.Stringer](sb *strings.Builder, mismatches []T) {
+	for i, m := range mismatches {
+		if i == maxReportedMismatches {
+			fmt.Fprintf(sb, "\n\t... and %d more",
+				len(mismatches)-maxReportedMismatches)
+			break
+		}
+
+		fmt.Fprintf(sb, "\n\t%s", m)
+	}
+}
@@ -0,0 +1,105 @@
+package memaccessagent
+
+import (
+	"testing"
+
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+)
+
+// dataCorrupter flips the bits of the first byte of every read response that
+// a port receives after skip responses.
+type dataCorrupter struct {
+	skip int
+}
+
+func (c *dataCorrupter) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosPortMsgRecvd {
+		return
+	}
+
+	rsp, ok := ctx.Item.(*mem.DataReadyRsp)
+	if !ok {
+		return
+	}
+
+	if c.skip > 0 {
+		c.skip--
+		return
+	}
+
+	rsp.Data[0] ^= 0xFF
+}
+
+func TestReadVerification(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt bool
+		verify  bool
+		wantErr bool
+	}{
+		{name: "correct data", verify: true},
+		{name: "wrong data", corrupt: true, verify: true, wantErr: true},
+		{name: "wrong data without verification", corrupt: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := sim.NewSerialEngine()
+
+			memCtrl := idealmemcontroller.MakeBuilder().
+				WithEngine(engine).
+				WithNewStorage(1 * mem.MB).
+				WithLatency(10).
+				Build("MemCtrl")
+
+			agent := MakeBuilder().
+				WithEngine(engine).
+				WithMaxAddress(4096).
+				WithWriteLeft(100).
+				WithReadLeft(100).
+				WithReadVerification(test.verify).
+				WithLowModule(memCtrl.GetPortByName("Top")).
+				Build("Agent")
+
+			conn := directconnection.MakeBuilder().
+				WithEngine(engine).
+				WithFreq(1 * sim.GHz).
+				Build("Conn")
+			conn.PlugIn(agent.GetPortByName("Mem"))
+			conn.PlugIn(memCtrl.GetPortByName("Top"))
+
+			if test.corrupt {
+				agent.GetPortByName("Mem").AcceptHook(&dataCorrupter{skip: 10})
+			}
+
+			agent.TickLater()
+
+			if err := engine.Run(); err != nil {
+				t.Fatalf("simulation failed: %v", err)
+			}
+
+			err := agent.VerificationError()
+			if test.wantErr != (err != nil) {
+				t.Fatalf("got %v, want an error: %t", err, test.wantErr)
+			}
+
+			if !test.wantErr {
+				return
+			}
+
+			mismatch := agent.ReadMismatches[0]
+			if mismatch.Offset != 0 || mismatch.NumWrongBytes != 1 {
+				t.Errorf("got %s, want only the first byte to be wrong",
+					mismatch)
+			}
+
+			if len(agent.ReadMismatches) != 90 {
+				t.Errorf("got %d mismatches, want one for each corrupted read",
+					len(agent.ReadMismatches))
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package memaccessagent
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// A TraceRecorder is a port hook that records the memory accesses that pass
+// through a port into a trace that a MemAccessAgent can replay. It can be
+// attached to any port that sends or receives ReadReqs and WriteReqs, for
+// example, the port that a compute unit uses to access its L1 cache.
+//
+// Accesses are written in the order that they are issued, once they and all
+// the earlier accesses have completed.
+type TraceRecorder struct {
+	timeTeller sim.TimeTeller
+	writer     TraceWriter
+	closer     io.Closer
+
+	inflight map[string]*TraceRecord
+	queue    []*recordingAccess
+	err      error
+
+	NumRecorded int
+}
+
+// recordingAccess is an access that has been seen but not written yet.
+type recordingAccess struct {
+	id     string
+	record TraceRecord
+}
+
+// NewTraceRecorder creates a TraceRecorder that writes to the given writer.
+// The time teller, usually the engine, provides the timestamps.
+func NewTraceRecorder(
+	timeTeller sim.TimeTeller,
+	writer TraceWriter,
+) *TraceRecorder {
+	return &TraceRecorder{
+		timeTeller: timeTeller,
+		writer:     writer,
+		inflight:   make(map[string]*TraceRecord),
+	}
+}
+
+// RecordPortTrace creates a trace file and records the accesses that pass
+// through the port into it. Files with the .csv extension are written as CSV
+// traces and all other files are written as binary traces. The returned
+// recorder must be closed at the end of the simulation.
+func RecordPortTrace(
+	port sim.Port,
+	timeTeller sim.TimeTeller,
+	path string,
+) (*TraceRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var writer TraceWriter
+	if strings.HasSuffix(path, ".csv") {
+		writer, err = NewCSVTraceWriter(f)
+	} else {
+		writer, err = NewBinaryTraceWriter(f)
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	recorder := NewTraceRecorder(timeTeller, writer)
+	recorder.closer = f
+	port.AcceptHook(recorder)
+
+	return recorder, nil
+}
+
+// Func records the message carried by the hook context.
+func (r *TraceRecorder) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosPortMsgSend && ctx.Pos != sim.HookPosPortMsgRecvd {
+		return
+	}
+
+	switch msg := ctx.Item.(type) {
+	case *mem.ReadReq:
+		r.startAccess(msg.ID, TraceRecord{
+			Address: msg.Address,
+			Size:    msg.AccessByteSize,
+			PID:     msg.PID,
+		})
+	case *mem.WriteReq:
+		r.startAccess(msg.ID, TraceRecord{
+			Write:   true,
+			Address: msg.Address,
+			Size:    uint64(len(msg.Data)),
+			PID:     msg.PID,
+			Data:    append([]byte(nil), msg.Data...),
+		})
+	case *mem.DataReadyRsp:
+		r.completeAccess(msg.RespondTo)
+	case *mem.WriteDoneRsp:
+		r.completeAccess(msg.RespondTo)
+	}
+}
+
+func (r *TraceRecorder) startAccess(id string, record TraceRecord) {
+	if _, found := r.inflight[id]; found {
+		return
+	}
+
+	record.Unit = TimeInSecond
+	record.Timestamp = float64(r.timeTeller.CurrentTime())
+
+	access := &recordingAccess{id: id, record: record}
+	r.inflight[id] = &access.record
+	r.queue = append(r.queue, access)
+}
+
+func (r *TraceRecorder) completeAccess(id string) {
+	record, found := r.inflight[id]
+	if !found || record.Completed {
+		return
+	}
+
+	record.Completed = true
+	record.CompleteTimestamp = float64(r.timeTeller.CurrentTime())
+
+	r.writeCompleted()
+}
+
+// writeCompleted writes the accesses at the head of the queue that have
+// completed.
+func (r *TraceRecorder) writeCompleted() {
+	for len(r.queue) > 0 && r.queue[0].record.Completed {
+		r.write(r.queue[0])
+		r.queue = r.queue[1:]
+	}
+}
+
+func (r *TraceRecorder) write(access *recordingAccess) {
+	delete(r.inflight, access.id)
+
+	if r.err != nil {
+		return
+	}
+
+	r.err = r.writer.Write(access.record)
+	if r.err == nil {
+		r.NumRecorded++
+	}
+}
+
+// Close writes the remaining accesses, including the ones that have not
+// completed, and closes the trace file if the recorder owns it. It returns
+// the first error that happened while recording.
+func (r *TraceRecorder) Close() error {
+	for _, access := range r.queue {
+		r.write(access)
+	}
+
+	r.queue = nil
+
+	if r.err == nil {
+		r.err = r.writer.Flush()
+	}
+
+	if r.closer != nil {
+		err := r.closer.Close()
+		if r.err == nil {
+			r.err = err
+		}
+
+		r.closer = nil
+	}
+
+	return r.err
+}
@@ -0,0 +1,130 @@
+package memaccessagent
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// traceReplay keeps the progress of replaying a trace.
+type traceReplay struct {
+	started        bool
+	done           bool
+	startTime      sim.VTimeInSec
+	firstTimestamp float64
+	nextRecord     *TraceRecord
+}
+
+// TraceDone returns true if the agent has issued all the accesses of its
+// trace.
+func (a *MemAccessAgent) TraceDone() bool {
+	return a.replay.done
+}
+
+// replayTrace issues the next access of the trace.
+func (a *MemAccessAgent) replayTrace() bool {
+	if a.replay.nextRecord == nil && !a.readNextRecord() {
+		return false
+	}
+
+	record := a.replay.nextRecord
+
+	if a.RespectTraceTime {
+		issueTime := a.traceIssueTime(record)
+		if issueTime > a.CurrentTime() {
+			a.wakeUpAt(issueTime)
+			return false
+		}
+	}
+
+	if record.Maintenance != NoMaintenance {
+		a.startMaintenance(record.Maintenance, int(record.Size))
+		a.replay.nextRecord = nil
+		a.NumReplayed++
+
+		return true
+	}
+
+	issued := false
+	if record.Write {
+		data := record.Data
+		if len(data) == 0 {
+			data = make([]byte, record.Size)
+			a.Rand.Read(data)
+		}
+
+		issued = a.sendWrite(record.Address, data, record.PID)
+	} else {
+		issued = a.sendRead(record.Address, record.Size, record.PID)
+	}
+
+	if issued {
+		a.replay.nextRecord = nil
+		a.NumReplayed++
+	}
+
+	return issued
+}
+
+func (a *MemAccessAgent) readNextRecord() bool {
+	if a.replay.done {
+		return false
+	}
+
+	record, err := a.Trace.Next()
+	if err == io.EOF {
+		a.replay.done = true
+		return false
+	}
+
+	if err == nil && record.Maintenance == NoMaintenance && record.Size == 0 {
+		err = errZeroSize
+	}
+
+	if err != nil {
+		a.replay.done = true
+		a.err = fmt.Errorf("%s: cannot replay the trace: %w", a.Name(), err)
+		a.stop("trace error")
+
+		return false
+	}
+
+	if !a.replay.started {
+		a.replay.started = true
+		a.replay.startTime = a.CurrentTime()
+		a.replay.firstTimestamp = record.Timestamp
+	}
+
+	a.replay.nextRecord = &record
+
+	return true
+}
+
+// Err returns the error that stopped the agent, such as a malformed trace
+// record, or nil.
+func (a *MemAccessAgent) Err() error {
+	return a.err
+}
+
+// Close closes the trace that the agent replays, if it has to be closed. It
+// must be called once the simulation completes, including when the agent stops
+// before the end of the trace.
+func (a *MemAccessAgent) Close() error {
+	if closer, ok := a.Trace.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// traceIssueTime converts the timestamp of a record to the simulation time,
+// assuming the first record of the trace is issued when the replay starts.
+func (a *MemAccessAgent) traceIssueTime(record *TraceRecord) sim.VTimeInSec {
+	offset := record.Timestamp - a.replay.firstTimestamp
+	if record.Unit == TimeInCycle {
+		offset *= float64(a.Freq.Period())
+	}
+
+	return a.Freq.ThisTick(a.replay.startTime + sim.VTimeInSec(offset))
+}
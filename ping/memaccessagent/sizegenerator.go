@@ -0,0 +1,181 @@
+package memaccessagent
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// errZeroSize is returned for the accesses and the size generators whose size
+// is 0.
+var errZeroSize = errors.New("access size must not be 0")
+
+// A SizeGenerator decides the number of bytes of each access of a
+// MemAccessAgent.
+type SizeGenerator interface {
+	// NextSize returns the size of the next access. The read argument tells
+	// if the access is a read or a write.
+	NextSize(read bool) uint64
+}
+
+// A sizeLimiter is a SizeGenerator that knows the largest size that it can
+// return. The builder uses it to check that the accesses fit in the address
+// range.
+type sizeLimiter interface {
+	MaxSize() uint64
+}
+
+// NewSizeGenerator creates one of the built-in size generators by name. The
+// supported distributions are "byte", "word", "line" (a 64-byte cache line),
+// "uniform" (any size from 1 to 128 bytes), and "mixed" (mostly words, with
+// some bytes, double words, 16-byte vectors, and lines). The random choices
+// are drawn from rng.
+func NewSizeGenerator(
+	distribution string,
+	rng *rand.Rand,
+) (SizeGenerator, error) {
+	switch distribution {
+	case "byte":
+		return NewFixedSizeGenerator(1)
+	case "word":
+		return NewFixedSizeGenerator(4)
+	case "line":
+		return NewFixedSizeGenerator(64)
+	case "uniform":
+		return NewUniformSizeGenerator(1, 128, rng)
+	case "mixed":
+		return NewMixedSizeGenerator(
+			[]uint64{1, 4, 8, 16, 64},
+			[]float64{1, 4, 2, 2, 1},
+			rng,
+		)
+	default:
+		return nil, fmt.Errorf("unknown size distribution %q", distribution)
+	}
+}
+
+// FixedSizeGenerator makes all the accesses the same size.
+type FixedSizeGenerator struct {
+	size uint64
+}
+
+// NewFixedSizeGenerator creates a FixedSizeGenerator. The size must not be 0.
+func NewFixedSizeGenerator(size uint64) (*FixedSizeGenerator, error) {
+	if size == 0 {
+		return nil, errZeroSize
+	}
+
+	return &FixedSizeGenerator{size: size}, nil
+}
+
+// NextSize returns the fixed size.
+func (g *FixedSizeGenerator) NextSize(_ bool) uint64 {
+	return g.size
+}
+
+// MaxSize returns the fixed size.
+func (g *FixedSizeGenerator) MaxSize() uint64 {
+	return g.size
+}
+
+// UniformSizeGenerator picks sizes uniformly between a minimum and a maximum,
+// both included.
+type UniformSizeGenerator struct {
+	min uint64
+	max uint64
+	rng *rand.Rand
+}
+
+// NewUniformSizeGenerator creates a UniformSizeGenerator. The minimum must
+// not be 0 and must not exceed the maximum.
+func NewUniformSizeGenerator(
+	min, max uint64,
+	rng *rand.Rand,
+) (*UniformSizeGenerator, error) {
+	if min == 0 {
+		return nil, errZeroSize
+	}
+
+	if min > max {
+		return nil, fmt.Errorf("minimum size %d is larger than "+
+			"the maximum size %d", min, max)
+	}
+
+	return &UniformSizeGenerator{min: min, max: max, rng: rng}, nil
+}
+
+// NextSize returns a random size in the range.
+func (g *UniformSizeGenerator) NextSize(_ bool) uint64 {
+	return g.min + g.rng.Uint64()%(g.max-g.min+1)
+}
+
+// MaxSize returns the maximum of the range.
+func (g *UniformSizeGenerator) MaxSize() uint64 {
+	return g.max
+}
+
+// MixedSizeGenerator picks sizes from a list, each with a relative weight.
+type MixedSizeGenerator struct {
+	sizes       []uint64
+	cumWeights  []float64
+	totalWeight float64
+	rng         *rand.Rand
+}
+
+// NewMixedSizeGenerator creates a MixedSizeGenerator. Each size has a weight,
+// which must not be negative. The weights do not need to sum to 1.
+func NewMixedSizeGenerator(
+	sizes []uint64,
+	weights []float64,
+	rng *rand.Rand,
+) (*MixedSizeGenerator, error) {
+	if len(sizes) == 0 || len(sizes) != len(weights) {
+		return nil, fmt.Errorf("got %d sizes and %d weights, want as many "+
+			"weights as sizes", len(sizes), len(weights))
+	}
+
+	g := &MixedSizeGenerator{sizes: sizes, rng: rng}
+	for i, w := range weights {
+		if sizes[i] == 0 {
+			return nil, errZeroSize
+		}
+
+		if w < 0 {
+			return nil, fmt.Errorf("weight %g of size %d is negative",
+				w, sizes[i])
+		}
+
+		g.totalWeight += w
+		g.cumWeights = append(g.cumWeights, g.totalWeight)
+	}
+
+	if g.totalWeight <= 0 {
+		return nil, errors.New("the weights of the sizes sum to 0")
+	}
+
+	return g, nil
+}
+
+// NextSize returns a size picked according to the weights.
+func (g *MixedSizeGenerator) NextSize(_ bool) uint64 {
+	dice := g.rng.Float64() * g.totalWeight
+	for i, w := range g.cumWeights {
+		if dice < w {
+			return g.sizes[i]
+		}
+	}
+
+	return g.sizes[len(g.sizes)-1]
+}
+
+// MaxSize returns the largest size in the list.
+func (g *MixedSizeGenerator) MaxSize() uint64 {
+	max := uint64(0)
+	for _, size := range g.sizes {
+		if size > max {
+			max = size
+		}
+	}
+
+	return max
+}
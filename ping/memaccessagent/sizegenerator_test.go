@@ -0,0 +1,113 @@
+package memaccessagent
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSizeGenerators(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name     string
+		create   func() (SizeGenerator, error)
+		min, max uint64
+		wantErr  bool
+	}{
+		{
+			name: "fixed",
+			create: func() (SizeGenerator, error) {
+				return NewFixedSizeGenerator(8)
+			},
+			min: 8,
+			max: 8,
+		},
+		{
+			name: "fixed zero",
+			create: func() (SizeGenerator, error) {
+				return NewFixedSizeGenerator(0)
+			},
+			wantErr: true,
+		},
+		{
+			name: "uniform",
+			create: func() (SizeGenerator, error) {
+				return NewUniformSizeGenerator(2, 6, rng)
+			},
+			min: 2,
+			max: 6,
+		},
+		{
+			name: "uniform from zero",
+			create: func() (SizeGenerator, error) {
+				return NewUniformSizeGenerator(0, 6, rng)
+			},
+			wantErr: true,
+		},
+		{
+			name: "uniform with min above max",
+			create: func() (SizeGenerator, error) {
+				return NewUniformSizeGenerator(8, 4, rng)
+			},
+			wantErr: true,
+		},
+		{
+			name: "mixed",
+			create: func() (SizeGenerator, error) {
+				return NewMixedSizeGenerator(
+					[]uint64{4, 64}, []float64{1, 0}, rng)
+			},
+			min: 4,
+			max: 4,
+		},
+		{
+			name: "mixed with fewer weights",
+			create: func() (SizeGenerator, error) {
+				return NewMixedSizeGenerator(
+					[]uint64{4, 64}, []float64{1}, rng)
+			},
+			wantErr: true,
+		},
+		{
+			name: "mixed with a zero size",
+			create: func() (SizeGenerator, error) {
+				return NewMixedSizeGenerator(
+					[]uint64{0, 64}, []float64{1, 1}, rng)
+			},
+			wantErr: true,
+		},
+		{
+			name: "mixed with zero weights",
+			create: func() (SizeGenerator, error) {
+				return NewMixedSizeGenerator(
+					[]uint64{4, 64}, []float64{0, 0}, rng)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := test.create()
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 100; i++ {
+				size := g.NextSize(i%2 == 0)
+				if size < test.min || size > test.max {
+					t.Fatalf("got size %d, want a size in [%d, %d]",
+						size, test.min, test.max)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package memaccessagent
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sarchlab/akita/v4/sim"
+
+	"github.com/sarchlab/yuzawa_example/metrics_reporter"
+)
+
+// defaultOccupancyWindow is the number of cycles covered by each occupancy
+// sample.
+const defaultOccupancyWindow = 10000
+
+// A RequestTiming records when a request was sent and when its response
+// arrived.
+type RequestTiming struct {
+	ID           string
+	Read         bool
+	Address      uint64
+	Size         uint64
+	IssueTime    sim.VTimeInSec
+	CompleteTime sim.VTimeInSec
+
+	warmup bool
+}
+
+// Latency returns the time from sending the request to receiving its
+// response.
+func (t RequestTiming) Latency() sim.VTimeInSec {
+	return t.CompleteTime - t.IssueTime
+}
+
+// An OccupancySample summarizes a window of time: the average number of
+// requests in flight and the number of bytes that completed.
+type OccupancySample struct {
+	Start              sim.VTimeInSec
+	End                sim.VTimeInSec
+	AverageOutstanding float64
+	BytesCompleted     uint64
+}
+
+// Bandwidth returns the number of bytes completed per second in the window.
+func (s OccupancySample) Bandwidth() float64 {
+	if s.End <= s.Start {
+		return 0
+	}
+
+	return float64(s.BytesCompleted) / float64(s.End-s.Start)
+}
+
+// updateOccupancy closes the occupancy windows that end before now and
+// accumulates the number of requests in flight until now. It must be called
+// before the number of requests in flight changes.
+func (a *MemAccessAgent) updateOccupancy(now sim.VTimeInSec) {
+	for a.OccupancyWindow > 0 {
+		windowEnd := a.windowStart +
+			sim.VTimeInSec(a.OccupancyWindow)*a.Freq.Period()
+		if now < windowEnd {
+			break
+		}
+
+		a.accumulateOccupancy(windowEnd)
+		a.Occupancy = append(a.Occupancy, a.currentWindow(windowEnd))
+		a.windowArea = 0
+		a.windowBytes = 0
+		a.windowStart = windowEnd
+	}
+
+	a.accumulateOccupancy(now)
+}
+
+func (a *MemAccessAgent) accumulateOccupancy(t sim.VTimeInSec) {
+	area := float64(a.outstanding) * float64(t-a.lastOccupancyChange)
+	a.occupancyArea += area
+	a.windowArea += area
+	a.lastOccupancyChange = t
+}
+
+func (a *MemAccessAgent) currentWindow(end sim.VTimeInSec) OccupancySample {
+	return OccupancySample{
+		Start:              a.windowStart,
+		End:                end,
+		AverageOutstanding: a.windowArea / float64(end-a.windowStart),
+		BytesCompleted:     a.windowBytes,
+	}
+}
+
+// OccupancySamples returns the occupancy windows, including the last window
+// that is cut short by the last response.
+func (a *MemAccessAgent) OccupancySamples() []OccupancySample {
+	samples := slices.Clone(a.Occupancy)
+	if a.lastOccupancyChange > a.windowStart {
+		samples = append(samples, a.currentWindow(a.lastOccupancyChange))
+	}
+
+	return samples
+}
+
+// AverageOutstanding returns the average number of requests in flight, from
+// the first request to the last response.
+func (a *MemAccessAgent) AverageOutstanding() float64 {
+	duration := a.lastCompleteTime - a.firstIssueTime
+	if duration <= 0 {
+		return 0
+	}
+
+	return a.occupancyArea / float64(duration)
+}
+
+// A MetricReporter collects the metrics and the time series of a simulation.
+type MetricReporter interface {
+	AddMetric(location, what string, value float64, unit string)
+	AddSample(sample metrics_reporter.Sample)
+}
+
+// ReportMetrics adds the latency, bandwidth and occupancy statistics of the
+// agent to a reporter. The occupancy windows are reported as time series.
+func (a *MemAccessAgent) ReportMetrics(r MetricReporter) {
+	name := a.Name()
+
+	r.AddMetric(name, "bandwidth", a.Bandwidth(), "B/s")
+	r.AddMetric(name, "read_bandwidth", a.bandwidthOf(a.BytesRead), "B/s")
+	r.AddMetric(name, "write_bandwidth", a.bandwidthOf(a.BytesWritten), "B/s")
+	r.AddMetric(name, "avg_outstanding", a.AverageOutstanding(), "count")
+	r.AddMetric(name, "max_outstanding", float64(a.PeakOutstanding), "count")
+
+	reportLatency(r, name, "read", a.ReadLatency)
+	reportLatency(r, name, "write", a.WriteLatency)
+
+	maintenance := []struct {
+		kind string
+		h    *metrics_reporter.LatencyHistogram
+	}{
+		{"flush", a.FlushLatency},
+		{"invalidate", a.InvalidateLatency},
+		{"pause", a.PauseLatency},
+		{"restart", a.RestartLatency},
+	}
+	for _, m := range maintenance {
+		if m.h.Count() > 0 {
+			reportLatency(r, name, m.kind, m.h)
+		}
+	}
+
+	for _, s := range a.OccupancySamples() {
+		r.AddSample(metrics_reporter.Sample{
+			Start:    s.Start,
+			End:      s.End,
+			Location: name,
+			What:     "avg_outstanding",
+			Value:    s.AverageOutstanding,
+			Unit:     "count",
+		})
+		r.AddSample(metrics_reporter.Sample{
+			Start:    s.Start,
+			End:      s.End,
+			Location: name,
+			What:     "bandwidth",
+			Value:    s.Bandwidth(),
+			Unit:     "B/s",
+		})
+	}
+}
+
+func reportLatency(
+	r MetricReporter,
+	name, kind string,
+	h *metrics_reporter.LatencyHistogram,
+) {
+	r.AddMetric(name, kind+"_count", float64(h.Count()), "count")
+	if h.Count() == 0 {
+		return
+	}
+
+	r.AddMetric(name, kind+"_average_latency", float64(h.Mean()), "second")
+	h.Report(r, name, kind+"_latency")
+}
+
+func (a *MemAccessAgent) bandwidthOf(bytes uint64) float64 {
+	duration := a.lastCompleteTime - a.firstIssueTime
+	if duration <= 0 {
+		return 0
+	}
+
+	return float64(bytes) / float64(duration)
+}
+
+// PrintSummary prints the end time of the simulation and the latency,
+// bandwidth and maintenance statistics of the agent.
+func (a *MemAccessAgent) PrintSummary() {
+	fmt.Printf("End time: %.10f seconds\n", a.CurrentTime())
+	if a.Stopped() {
+		fmt.Printf("Stopped by %s after %d requests\n",
+			a.StopReason, a.NumIssued)
+	}
+	fmt.Printf("Average latency: %.10f seconds, bandwidth: %.2f GB/s\n",
+		a.AverageLatency(), a.Bandwidth()/1e9)
+	fmt.Printf("Read latency p50: %.10f, p99: %.10f seconds; "+
+		"write latency p50: %.10f, p99: %.10f seconds\n",
+		a.ReadLatency.Percentile(50), a.ReadLatency.Percentile(99),
+		a.WriteLatency.Percentile(50), a.WriteLatency.Percentile(99))
+	if n := a.FlushLatency.Count() + a.InvalidateLatency.Count() +
+		a.PauseLatency.Count(); n > 0 {
+		fmt.Printf("Maintenance: %d flushes (%.10f seconds), "+
+			"%d invalidations (%.10f seconds), %d pauses (%.10f seconds)\n",
+			a.FlushLatency.Count(), a.FlushLatency.Mean(),
+			a.InvalidateLatency.Count(), a.InvalidateLatency.Mean(),
+			a.PauseLatency.Count(), a.PauseLatency.Mean())
+	}
+}
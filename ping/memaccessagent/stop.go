@@ -0,0 +1,141 @@
+package memaccessagent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// steadyStateWindows is the number of consecutive latency windows that must
+// agree before the agent considers that it has reached a steady state.
+const steadyStateWindows = 3
+
+// checkStopTime stops the agent once the time limit is reached.
+func (a *MemAccessAgent) checkStopTime() {
+	if a.StopTime > 0 && a.CurrentTime() >= a.StopTime {
+		a.stop("time limit")
+	}
+}
+
+// stop makes the agent stop issuing requests. The requests in flight still
+// complete.
+func (a *MemAccessAgent) stop(reason string) {
+	if a.StopReason != "" {
+		return
+	}
+
+	a.StopReason = reason
+}
+
+// Stopped tells if the agent has stopped issuing requests before running out
+// of work, because of a time limit or a steady state.
+func (a *MemAccessAgent) Stopped() bool {
+	return a.StopReason != ""
+}
+
+// checkSteadyState adds a latency to the current window and stops the agent
+// once the average latencies of the last windows are within the tolerance.
+func (a *MemAccessAgent) checkSteadyState(latency sim.VTimeInSec) {
+	if a.SteadyStateWindow <= 0 {
+		return
+	}
+
+	a.steadyWindowLatency += latency
+	a.steadyWindowCount++
+	if a.steadyWindowCount < a.SteadyStateWindow {
+		return
+	}
+
+	mean := a.steadyWindowLatency / sim.VTimeInSec(a.steadyWindowCount)
+	a.steadyWindowLatency = 0
+	a.steadyWindowCount = 0
+
+	a.steadyMeans = append(a.steadyMeans, mean)
+	if len(a.steadyMeans) > steadyStateWindows {
+		a.steadyMeans = a.steadyMeans[1:]
+	}
+
+	if len(a.steadyMeans) < steadyStateWindows {
+		return
+	}
+
+	lowest, highest := a.steadyMeans[0], a.steadyMeans[0]
+	for _, m := range a.steadyMeans {
+		lowest = min(lowest, m)
+		highest = max(highest, m)
+	}
+
+	if float64(highest-lowest) <= a.SteadyStateTolerance*float64(lowest) {
+		a.stop("steady state")
+	}
+}
+
+// startMeasurement discards the statistics collected during the warmup.
+func (a *MemAccessAgent) startMeasurement(now sim.VTimeInSec) {
+	a.firstIssueTime = now
+	a.windowStart = now
+	a.occupancyArea = 0
+	a.windowArea = 0
+	a.windowBytes = 0
+	a.Occupancy = nil
+	a.PeakOutstanding = a.outstanding
+}
+
+// PendingSummary describes the requests in flight, for diagnosing a run that
+// does not complete.
+func (a *MemAccessAgent) PendingSummary() string {
+	var oldest *RequestTiming
+	for _, t := range a.inflight {
+		if oldest == nil || t.IssueTime < oldest.IssueTime {
+			oldest = &t
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d reads and %d writes pending, "+
+		"%d reads and %d writes left",
+		a.Name(), len(a.PendingReadReq), len(a.PendingWriteReq),
+		a.ReadLeft, a.WriteLeft)
+
+	if oldest != nil {
+		kind := "write"
+		if oldest.Read {
+			kind = "read"
+		}
+
+		fmt.Fprintf(&b, ", oldest is %s %s of %d bytes at 0x%X issued at %.10f",
+			kind, oldest.ID, oldest.Size, oldest.Address, oldest.IssueTime)
+	}
+
+	return b.String()
+}
+
+// CompletionError returns an error if the agent has not completed its work:
+// requests are still in flight, or requests or trace accesses are left
+// although the agent has not stopped early.
+func (a *MemAccessAgent) CompletionError() error {
+	if len(a.PendingReadReq) > 0 || len(a.PendingWriteReq) > 0 {
+		return fmt.Errorf("there are still pending requests: %s",
+			a.PendingSummary())
+	}
+
+	if a.maintenance != nil {
+		return fmt.Errorf("%s: a cache %s is still in progress",
+			a.Name(), a.maintenance.kind)
+	}
+
+	if !a.Stopped() {
+		if a.Trace != nil && !a.TraceDone() {
+			return fmt.Errorf("the trace is not fully replayed: %s, "+
+				"%d accesses replayed", a.Name(), a.NumReplayed)
+		}
+
+		if a.WriteLeft > 0 || a.ReadLeft > 0 {
+			return fmt.Errorf("there are still requests left: %s",
+				a.PendingSummary())
+		}
+	}
+
+	return nil
+}
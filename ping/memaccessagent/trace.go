@@ -0,0 +1,551 @@
+package memaccessagent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// TimeUnit tells how the timestamp of a trace record is measured.
+type TimeUnit int
+
+// The timestamps of a trace are either in seconds or in cycles.
+const (
+	TimeInSecond TimeUnit = iota
+	TimeInCycle
+)
+
+// A TraceRecord is one memory access in a trace.
+type TraceRecord struct {
+	Timestamp float64
+	Unit      TimeUnit
+	Write     bool
+	Address   uint64
+	Size      uint64
+	PID       vm.PID
+
+	// Data is the data to write. It can be empty, in which case the agent
+	// writes random data; otherwise, its length must be Size.
+	Data []byte
+
+	// CompleteTimestamp is the time that the access completed, if Completed
+	// is true. Replaying does not use it; it is kept for analysis.
+	Completed         bool
+	CompleteTimestamp float64
+
+	// Maintenance, if set, makes the record a cache maintenance operation
+	// instead of an access. The address is not used, and the size of a pause
+	// is the number of cycles that the caches stay paused.
+	Maintenance MaintenanceKind
+}
+
+// A TraceReader reads memory access records from a trace.
+type TraceReader interface {
+	// Next returns the next record of the trace, or io.EOF if there are no
+	// more records.
+	Next() (TraceRecord, error)
+}
+
+// OpenTrace opens a trace file. Files with the .csv extension are read as
+// CSV traces and all other files are read as binary traces.
+func OpenTrace(path string) (TraceReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader TraceReader
+	if strings.HasSuffix(path, ".csv") {
+		reader, err = NewCSVTraceReader(f)
+	} else {
+		reader, err = NewBinaryTraceReader(f)
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &fileTraceReader{TraceReader: reader, file: f, path: path}, nil
+}
+
+// fileTraceReader adds the path of the trace file to the errors and closes the
+// file.
+type fileTraceReader struct {
+	TraceReader
+	file   *os.File
+	path   string
+	closed bool
+}
+
+func (r *fileTraceReader) Next() (TraceRecord, error) {
+	record, err := r.TraceReader.Next()
+	if err != nil && err != io.EOF {
+		return record, fmt.Errorf("%s: %w", r.path, err)
+	}
+
+	return record, err
+}
+
+// Close closes the trace file. Closing it again has no effect.
+func (r *fileTraceReader) Close() error {
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+
+	return r.file.Close()
+}
+
+// CSVTraceReader reads traces in the CSV format. The first line is a header
+// whose first column is either "time", in seconds, or "cycle". The following
+// columns are "op" (R or W), "address", "size", "pid", an optional "data"
+// column that holds the written bytes in hexadecimal, and an optional "done"
+// column with the completion time. Addresses can be written in decimal or
+// with the 0x prefix. The maintenance operations F (flush), I (invalidate)
+// and P (pause) only need the time and the op, and a pause can give the
+// number of paused cycles in the size column.
+type CSVTraceReader struct {
+	reader *csv.Reader
+	unit   TimeUnit
+	line   int
+}
+
+// NewCSVTraceReader creates a CSVTraceReader and reads the header.
+func NewCSVTraceReader(r io.Reader) (*CSVTraceReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trace header: %w", err)
+	}
+
+	t := &CSVTraceReader{reader: reader, line: 1}
+	switch strings.ToLower(header[0]) {
+	case "time":
+		t.unit = TimeInSecond
+	case "cycle":
+		t.unit = TimeInCycle
+	default:
+		return nil, fmt.Errorf("unknown time column %q in trace header",
+			header[0])
+	}
+
+	return t, nil
+}
+
+// Next returns the next record of the trace.
+func (t *CSVTraceReader) Next() (TraceRecord, error) {
+	fields, err := t.reader.Read()
+	if err != nil {
+		return TraceRecord{}, err
+	}
+
+	t.line++
+
+	record, err := t.parse(fields)
+	if err != nil {
+		return TraceRecord{}, fmt.Errorf("trace line %d: %w", t.line, err)
+	}
+
+	return record, nil
+}
+
+func (t *CSVTraceReader) parse(fields []string) (TraceRecord, error) {
+	if len(fields) < 2 {
+		return TraceRecord{}, errors.New("too few fields")
+	}
+
+	record := TraceRecord{Unit: t.unit, PID: 1}
+
+	var err error
+	record.Timestamp, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return TraceRecord{}, err
+	}
+
+	switch strings.ToUpper(fields[1]) {
+	case "R", "READ":
+		record.Write = false
+	case "W", "WRITE":
+		record.Write = true
+	case "F", "FLUSH":
+		record.Maintenance = MaintenanceFlush
+	case "I", "INVALIDATE":
+		record.Maintenance = MaintenanceInvalidate
+	case "P", "PAUSE":
+		record.Maintenance = MaintenancePause
+	default:
+		return TraceRecord{}, fmt.Errorf("unknown operation %q", fields[1])
+	}
+
+	if record.Maintenance != NoMaintenance {
+		if len(fields) > 3 && fields[3] != "" {
+			record.Size, err = strconv.ParseUint(fields[3], 0, 64)
+		}
+
+		return record, err
+	}
+
+	if len(fields) < 4 {
+		return TraceRecord{}, errors.New("too few fields")
+	}
+
+	record.Address, err = strconv.ParseUint(fields[2], 0, 64)
+	if err != nil {
+		return TraceRecord{}, err
+	}
+
+	record.Size, err = strconv.ParseUint(fields[3], 0, 64)
+	if err != nil {
+		return TraceRecord{}, err
+	}
+
+	if record.Size == 0 {
+		return TraceRecord{}, errZeroSize
+	}
+
+	if len(fields) > 4 && fields[4] != "" {
+		pid, err := strconv.ParseUint(fields[4], 0, 32)
+		if err != nil {
+			return TraceRecord{}, err
+		}
+
+		record.PID = vm.PID(pid)
+	}
+
+	if len(fields) > 5 && fields[5] != "" {
+		record.Data, err = hex.DecodeString(fields[5])
+		if err != nil {
+			return TraceRecord{}, err
+		}
+
+		if uint64(len(record.Data)) != record.Size {
+			return TraceRecord{}, errDataSize
+		}
+	}
+
+	if len(fields) > 6 && fields[6] != "" {
+		record.CompleteTimestamp, err = strconv.ParseFloat(fields[6], 64)
+		if err != nil {
+			return TraceRecord{}, err
+		}
+
+		record.Completed = true
+	}
+
+	return record, nil
+}
+
+// errDataSize is returned for records that carry data of a different length
+// than their size.
+var errDataSize = errors.New("data length does not match size")
+
+// binaryTraceMagic starts every binary trace.
+var binaryTraceMagic = [4]byte{'M', 'A', 'T', 'R'}
+
+const (
+	binaryTraceVersion = 1
+
+	binaryFlagWrite       = 1 << 0
+	binaryFlagHasData     = 1 << 1
+	binaryFlagHasComplete = 1 << 2
+
+	binaryMaintenanceShift = 3
+	binaryMaintenanceMask  = 3 << binaryMaintenanceShift
+
+	binaryKnownFlags = binaryFlagWrite | binaryFlagHasData |
+		binaryFlagHasComplete | binaryMaintenanceMask
+)
+
+// maxBinaryTraceDataSize is the largest data that a binary trace record can
+// carry, so that a corrupted size does not make the reader allocate an
+// arbitrary amount of memory.
+const maxBinaryTraceDataSize = 1 << 20
+
+// BinaryTraceReader reads traces in the compact binary format. The trace
+// starts with the magic "MATR", a version byte, and a time unit byte (0 for
+// seconds, 1 for cycles). Each record then holds, in little endian, a flag
+// byte (bit 0 for writes, bit 1 if data follows, bit 2 if the completion time
+// follows, bits 3 and 4 for the maintenance operation), the timestamp (a
+// float64 for seconds or a uint64 for cycles), the address as a uint64, the
+// size and the PID as uint32s, the completion time in the same format as the
+// timestamp if present, and the data, at most 1 MiB, if present.
+type BinaryTraceReader struct {
+	reader *bufio.Reader
+	unit   TimeUnit
+	record int
+}
+
+// NewBinaryTraceReader creates a BinaryTraceReader and reads the header.
+func NewBinaryTraceReader(r io.Reader) (*BinaryTraceReader, error) {
+	reader := bufio.NewReader(r)
+
+	header := make([]byte, 6)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trace header: %w", err)
+	}
+
+	if [4]byte(header[:4]) != binaryTraceMagic {
+		return nil, errors.New("not a binary memory trace")
+	}
+
+	if header[4] != binaryTraceVersion {
+		return nil, fmt.Errorf("unsupported trace version %d", header[4])
+	}
+
+	unit := TimeUnit(header[5])
+	if unit != TimeInSecond && unit != TimeInCycle {
+		return nil, fmt.Errorf("unknown trace time unit %d", header[5])
+	}
+
+	return &BinaryTraceReader{
+		reader: reader,
+		unit:   unit,
+	}, nil
+}
+
+// Next returns the next record of the trace.
+func (t *BinaryTraceReader) Next() (TraceRecord, error) {
+	buf := make([]byte, 25)
+	t.record++
+
+	_, err := io.ReadFull(t.reader, buf)
+	if err == io.ErrUnexpectedEOF {
+		return TraceRecord{}, t.truncated()
+	}
+
+	if err != nil {
+		return TraceRecord{}, err
+	}
+
+	flags := buf[0]
+	if flags&^binaryKnownFlags != 0 {
+		return TraceRecord{}, fmt.Errorf("trace record %d: unknown flags 0x%x",
+			t.record, flags)
+	}
+
+	record := TraceRecord{
+		Unit:    t.unit,
+		Write:   flags&binaryFlagWrite != 0,
+		Address: binary.LittleEndian.Uint64(buf[9:17]),
+		Size:    uint64(binary.LittleEndian.Uint32(buf[17:21])),
+		PID:     vm.PID(binary.LittleEndian.Uint32(buf[21:25])),
+		Maintenance: MaintenanceKind(
+			flags & binaryMaintenanceMask >> binaryMaintenanceShift),
+	}
+
+	record.Timestamp = t.decodeTime(buf[1:9])
+
+	if record.Maintenance == NoMaintenance && record.Size == 0 {
+		return TraceRecord{}, fmt.Errorf("trace record %d: %w",
+			t.record, errZeroSize)
+	}
+
+	if flags&binaryFlagHasComplete != 0 {
+		_, err = io.ReadFull(t.reader, buf[:8])
+		if err != nil {
+			return TraceRecord{}, t.truncated()
+		}
+
+		record.Completed = true
+		record.CompleteTimestamp = t.decodeTime(buf[:8])
+	}
+
+	if flags&binaryFlagHasData != 0 {
+		if record.Size > maxBinaryTraceDataSize {
+			return TraceRecord{}, fmt.Errorf(
+				"trace record %d: data of %d bytes is too large",
+				t.record, record.Size)
+		}
+
+		record.Data = make([]byte, record.Size)
+
+		_, err = io.ReadFull(t.reader, record.Data)
+		if err != nil {
+			return TraceRecord{}, t.truncated()
+		}
+	}
+
+	return record, nil
+}
+
+func (t *BinaryTraceReader) truncated() error {
+	return fmt.Errorf("trace record %d: truncated", t.record)
+}
+
+func (t *BinaryTraceReader) decodeTime(buf []byte) float64 {
+	v := binary.LittleEndian.Uint64(buf)
+	if t.unit == TimeInCycle {
+		return float64(v)
+	}
+
+	return math.Float64frombits(v)
+}
+
+// A TraceWriter writes memory access records to a trace.
+type TraceWriter interface {
+	// Write appends a record to the trace.
+	Write(record TraceRecord) error
+
+	// Flush writes the buffered records to the underlying writer.
+	Flush() error
+}
+
+// CSVTraceWriter writes traces with timestamps in seconds in the format read
+// by CSVTraceReader.
+type CSVTraceWriter struct {
+	writer *csv.Writer
+}
+
+// NewCSVTraceWriter creates a CSVTraceWriter and writes the header.
+func NewCSVTraceWriter(w io.Writer) (*CSVTraceWriter, error) {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(
+		[]string{"time", "op", "address", "size", "pid", "data", "done"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CSVTraceWriter{writer: writer}, nil
+}
+
+// Write appends a record to the trace.
+func (t *CSVTraceWriter) Write(record TraceRecord) error {
+	if record.Unit != TimeInSecond {
+		return errors.New("CSV trace writer only supports time in seconds")
+	}
+
+	if len(record.Data) > 0 && uint64(len(record.Data)) != record.Size {
+		return errDataSize
+	}
+
+	op := "R"
+	switch {
+	case record.Maintenance != NoMaintenance:
+		op = strings.ToUpper(record.Maintenance.String()[:1])
+	case record.Write:
+		op = "W"
+	}
+
+	done := ""
+	if record.Completed {
+		done = strconv.FormatFloat(record.CompleteTimestamp, 'g', -1, 64)
+	}
+
+	return t.writer.Write([]string{
+		strconv.FormatFloat(record.Timestamp, 'g', -1, 64),
+		op,
+		fmt.Sprintf("0x%x", record.Address),
+		strconv.FormatUint(record.Size, 10),
+		strconv.FormatUint(uint64(record.PID), 10),
+		hex.EncodeToString(record.Data),
+		done,
+	})
+}
+
+// Flush writes the buffered records to the underlying writer.
+func (t *CSVTraceWriter) Flush() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+// BinaryTraceWriter writes traces with timestamps in seconds in the format
+// read by BinaryTraceReader.
+type BinaryTraceWriter struct {
+	writer *bufio.Writer
+}
+
+// NewBinaryTraceWriter creates a BinaryTraceWriter and writes the header.
+func NewBinaryTraceWriter(w io.Writer) (*BinaryTraceWriter, error) {
+	writer := bufio.NewWriter(w)
+
+	header := append(binaryTraceMagic[:],
+		binaryTraceVersion, byte(TimeInSecond))
+
+	_, err := writer.Write(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BinaryTraceWriter{writer: writer}, nil
+}
+
+// Write appends a record to the trace.
+func (t *BinaryTraceWriter) Write(record TraceRecord) error {
+	if record.Unit != TimeInSecond {
+		return errors.New("binary trace writer only supports time in seconds")
+	}
+
+	if len(record.Data) > 0 && uint64(len(record.Data)) != record.Size {
+		return errDataSize
+	}
+
+	if record.Size > math.MaxUint32 {
+		return fmt.Errorf("size %d does not fit in a binary trace",
+			record.Size)
+	}
+
+	if len(record.Data) > maxBinaryTraceDataSize {
+		return fmt.Errorf("data of %d bytes does not fit in a binary trace",
+			len(record.Data))
+	}
+
+	flags := byte(0)
+	if record.Write {
+		flags |= binaryFlagWrite
+	}
+
+	if len(record.Data) > 0 {
+		flags |= binaryFlagHasData
+	}
+
+	if record.Completed {
+		flags |= binaryFlagHasComplete
+	}
+
+	flags |= byte(record.Maintenance) << binaryMaintenanceShift &
+		binaryMaintenanceMask
+
+	buf := make([]byte, 25, 33)
+	buf[0] = flags
+	binary.LittleEndian.PutUint64(buf[1:9], math.Float64bits(record.Timestamp))
+	binary.LittleEndian.PutUint64(buf[9:17], record.Address)
+	binary.LittleEndian.PutUint32(buf[17:21], uint32(record.Size))
+	binary.LittleEndian.PutUint32(buf[21:25], uint32(record.PID))
+
+	if record.Completed {
+		buf = binary.LittleEndian.AppendUint64(
+			buf, math.Float64bits(record.CompleteTimestamp))
+	}
+
+	_, err := t.writer.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.writer.Write(record.Data)
+
+	return err
+}
+
+// Flush writes the buffered records to the underlying writer.
+func (t *BinaryTraceWriter) Flush() error {
+	return t.writer.Flush()
+}
@@ -0,0 +1,287 @@
+package memaccessagent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraceRoundTrip(t *testing.T) {
+	records := []TraceRecord{
+		{Timestamp: 1e-9, Address: 0x40, Size: 4, PID: 1},
+		{
+			Timestamp: 2.5e-9,
+			Write:     true,
+			Address:   0x1000,
+			Size:      4,
+			PID:       2,
+			Data:      []byte{1, 2, 3, 4},
+		},
+		{Timestamp: 3e-9, Write: true, Address: 0x2000, Size: 64, PID: 1},
+		{
+			Timestamp:         4e-9,
+			Address:           0xffff_ffff_0000,
+			Size:              8,
+			PID:               3,
+			Completed:         true,
+			CompleteTimestamp: 1.25e-8,
+		},
+		{Timestamp: 5e-9, PID: 1, Maintenance: MaintenanceFlush},
+		{Timestamp: 6e-9, PID: 1, Maintenance: MaintenanceInvalidate},
+		{Timestamp: 7e-9, Size: 100, PID: 1, Maintenance: MaintenancePause},
+	}
+
+	formats := []struct {
+		name      string
+		newWriter func(w io.Writer) (TraceWriter, error)
+		newReader func(r io.Reader) (TraceReader, error)
+	}{
+		{
+			name: "csv",
+			newWriter: func(w io.Writer) (TraceWriter, error) {
+				return NewCSVTraceWriter(w)
+			},
+			newReader: func(r io.Reader) (TraceReader, error) {
+				return NewCSVTraceReader(r)
+			},
+		},
+		{
+			name: "binary",
+			newWriter: func(w io.Writer) (TraceWriter, error) {
+				return NewBinaryTraceWriter(w)
+			},
+			newReader: func(r io.Reader) (TraceReader, error) {
+				return NewBinaryTraceReader(r)
+			},
+		},
+	}
+
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			writer, err := format.newWriter(&buf)
+			if err != nil {
+				t.Fatalf("cannot create the writer: %v", err)
+			}
+
+			for _, record := range records {
+				if err := writer.Write(record); err != nil {
+					t.Fatalf("cannot write %+v: %v", record, err)
+				}
+			}
+
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("cannot flush: %v", err)
+			}
+
+			reader, err := format.newReader(&buf)
+			if err != nil {
+				t.Fatalf("cannot create the reader: %v", err)
+			}
+
+			for i, want := range records {
+				got, err := reader.Next()
+				if err != nil {
+					t.Fatalf("record %d: %v", i, err)
+				}
+
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("record %d: got %+v, want %+v", i, got, want)
+				}
+			}
+
+			if _, err := reader.Next(); err != io.EOF {
+				t.Errorf("got %v after the last record, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestTraceWritersRejectDataOfAnotherSize(t *testing.T) {
+	record := TraceRecord{
+		Timestamp: 1e-9,
+		Write:     true,
+		Address:   0x40,
+		Size:      4,
+		Data:      []byte{1, 2},
+	}
+
+	csvWriter, err := NewCSVTraceWriter(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binaryWriter, err := NewBinaryTraceWriter(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writers := map[string]TraceWriter{
+		"csv":    csvWriter,
+		"binary": binaryWriter,
+	}
+
+	for name, writer := range writers {
+		if err := writer.Write(record); !errors.Is(err, errDataSize) {
+			t.Errorf("%s: got %v, want %v", name, err, errDataSize)
+		}
+	}
+}
+
+func TestCSVTraceReaderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		trace   string
+		wantErr string
+	}{
+		{
+			name:    "unknown operation",
+			trace:   "time,op,address,size\n1e-9,R,0x40,4\n2e-9,X,0x40,4\n",
+			wantErr: `trace line 3: unknown operation "X"`,
+		},
+		{
+			name:    "data of another size",
+			trace:   "time,op,address,size,pid,data\n1e-9,W,0x40,4,1,0102\n",
+			wantErr: "trace line 2: " + errDataSize.Error(),
+		},
+		{
+			name:    "zero size",
+			trace:   "time,op,address,size\n1e-9,R,0x40,0\n",
+			wantErr: "trace line 2: " + errZeroSize.Error(),
+		},
+		{
+			name:    "too few fields",
+			trace:   "cycle,op,address,size\n10,R,0x40\n",
+			wantErr: "trace line 2: too few fields",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader, err := NewCSVTraceReader(strings.NewReader(test.trace))
+			if err != nil {
+				t.Fatalf("cannot read the header: %v", err)
+			}
+
+			for {
+				_, err = reader.Next()
+				if err != nil {
+					break
+				}
+			}
+
+			if err == io.EOF || err.Error() != test.wantErr {
+				t.Errorf("got %v, want %s", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBinaryTraceReaderTruncated(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := NewBinaryTraceWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := TraceRecord{
+		Timestamp: 1e-9,
+		Write:     true,
+		Size:      4,
+		Data:      []byte{1, 2, 3, 4},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := writer.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewBinaryTraceReader(
+		bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reader.Next(); err != nil {
+		t.Fatalf("record 1: %v", err)
+	}
+
+	_, err = reader.Next()
+	if err == nil || err.Error() != "trace record 2: truncated" {
+		t.Errorf("got %v, want the second record to be truncated", err)
+	}
+}
+
+func TestBinaryTraceReaderErrors(t *testing.T) {
+	header := func(unit byte) []byte {
+		return []byte{'M', 'A', 'T', 'R', binaryTraceVersion, unit}
+	}
+
+	record := func(flags byte, size uint32) []byte {
+		buf := make([]byte, 25)
+		buf[0] = flags
+		binary.LittleEndian.PutUint32(buf[17:21], size)
+
+		return buf
+	}
+
+	tests := []struct {
+		name    string
+		trace   []byte
+		wantErr string
+	}{
+		{
+			name:    "unknown time unit",
+			trace:   header(2),
+			wantErr: "unknown trace time unit 2",
+		},
+		{
+			name:    "unknown flags",
+			trace:   append(header(0), record(1<<5, 4)...),
+			wantErr: "trace record 1: unknown flags 0x20",
+		},
+		{
+			name: "data too large",
+			trace: append(header(0),
+				record(binaryFlagHasData, maxBinaryTraceDataSize+1)...),
+			wantErr: "trace record 1: data of 1048577 bytes is too large",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader, err := NewBinaryTraceReader(bytes.NewReader(test.trace))
+			if err == nil {
+				_, err = reader.Next()
+			}
+
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("got %v, want %s", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBinaryTraceWriterRejectsLargeSizes(t *testing.T) {
+	writer, err := NewBinaryTraceWriter(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := TraceRecord{Timestamp: 1e-9, Size: math.MaxUint32 + 1}
+	if err := writer.Write(record); err == nil {
+		t.Errorf("a size of %d is written", record.Size)
+	}
+}
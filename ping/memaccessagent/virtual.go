@@ -0,0 +1,248 @@
+package memaccessagent
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// defaultVirtualBase is the start of the virtual address range of an agent
+// that uses virtual addresses.
+const defaultVirtualBase = 0x100000000
+
+// A TranslationMismatch describes a translated request that does not match
+// any request that the agent has sent, which means that the address was
+// translated to the wrong physical address.
+type TranslationMismatch struct {
+	ReqID           string
+	PhysicalAddress uint64
+	VirtualAddress  uint64
+	Reason          string
+	Time            sim.VTimeInSec
+}
+
+// String returns a human-readable report of the mismatch.
+func (m TranslationMismatch) String() string {
+	return fmt.Sprintf(
+		"translation mismatch: req %s, physical address 0x%X, "+
+			"virtual address 0x%X, %s, at %.10f",
+		m.ReqID, m.PhysicalAddress, m.VirtualAddress, m.Reason, m.Time)
+}
+
+// addressBase returns the first address that the agent accesses.
+func (a *MemAccessAgent) addressBase() uint64 {
+	if a.UseVirtualAddress {
+		return a.VirtualBase
+	}
+
+	return 0
+}
+
+// memoryAddresses returns the golden memory addresses of the bytes of an
+// access. Virtual addresses are translated with the page table, so that the
+// processes that share pages also share values. Addresses that are not mapped
+// are used as they are.
+func (a *MemAccessAgent) memoryAddresses(addr, size uint64, pid vm.PID) []uint64 {
+	addrs := make([]uint64, size)
+
+	if !a.UseVirtualAddress || a.PageTable == nil {
+		for i := range addrs {
+			addrs[i] = addr + uint64(i)
+		}
+
+		return addrs
+	}
+
+	var page vm.Page
+	found := false
+
+	for i := range addrs {
+		vAddr := addr + uint64(i)
+		if !found || vAddr < page.VAddr || vAddr >= page.VAddr+page.PageSize {
+			page, found = a.PageTable.Find(pid, vAddr)
+		}
+
+		if !found {
+			addrs[i] = vAddr
+			continue
+		}
+
+		addrs[i] = page.PAddr + vAddr - page.VAddr
+	}
+
+	return addrs
+}
+
+// mapPages registers the pages of the virtual address range of the agent in
+// the page table. It does nothing if the pages are already mapped or if the
+// agent does not use virtual addresses.
+func (a *MemAccessAgent) mapPages() {
+	if a.pagesMapped || !a.UseVirtualAddress || a.PageTable == nil {
+		return
+	}
+
+	a.pagesMapped = true
+
+	pageSize := uint64(1) << a.Log2PageSize
+	numPages := (a.MaxAddress + pageSize - 1) / pageSize
+
+	frames := make([]uint64, numPages)
+	for i := range frames {
+		frames[i] = uint64(i)
+	}
+
+	switch a.PagePlacement {
+	case "", "contiguous":
+	case "random":
+		a.Rand.Shuffle(len(frames), func(i, j int) {
+			frames[i], frames[j] = frames[j], frames[i]
+		})
+	default:
+		panic(fmt.Sprintf("unknown page placement %q", a.PagePlacement))
+	}
+
+	a.frameToPage = make(map[uint64]uint64, numPages)
+	for i, frame := range frames {
+		vAddr := a.VirtualBase + uint64(i)*pageSize
+
+		// Another agent of the same process may have mapped the page.
+		if page, found := a.PageTable.Find(a.PID, vAddr); found {
+			a.frameToPage[page.PAddr>>a.Log2PageSize] = page.VAddr
+			continue
+		}
+
+		page := vm.Page{
+			PID:      a.PID,
+			VAddr:    vAddr,
+			PAddr:    a.PhysicalBase + frame*pageSize,
+			PageSize: pageSize,
+			Valid:    true,
+			DeviceID: a.DeviceID,
+			Unified:  true,
+		}
+
+		a.PageTable.Insert(page)
+		a.frameToPage[page.PAddr>>a.Log2PageSize] = page.VAddr
+	}
+}
+
+// CheckTranslation makes the agent validate the translated requests sent by
+// a port, usually the bottom port of the address translator. Each byte of a
+// translated request must map back to a byte that the agent has a pending
+// request of the same kind on, and translated writes must carry the data that
+// the agent wrote.
+//
+// The agent tags its requests with its port as the request info, which the
+// translator copies to the translated requests, so that the requests of other
+// agents that share the translator are not checked. The components between
+// the agent and the translator, such as a reorder buffer, may rebuild the
+// requests without the info. The untagged requests are then checked if they
+// access a frame that the agent has mapped, so agents that share pages should
+// not share a checked port behind such components.
+func (a *MemAccessAgent) CheckTranslation(port sim.Port) {
+	port.AcceptHook(&translationChecker{agent: a})
+}
+
+type translationChecker struct {
+	agent *MemAccessAgent
+}
+
+func (c *translationChecker) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosPortMsgSend {
+		return
+	}
+
+	switch req := ctx.Item.(type) {
+	case *mem.ReadReq:
+		if c.isFromAgent(req.Info, req.Address) {
+			c.agent.checkTranslatedRead(req)
+		}
+	case *mem.WriteReq:
+		if c.isFromAgent(req.Info, req.Address) {
+			c.agent.checkTranslatedWrite(req)
+		}
+	}
+}
+
+// isFromAgent tells if a translated request originates from the agent, based
+// on the info that the agent attached to the original request, or, if the
+// info was dropped, on the frame that the request accesses.
+func (c *translationChecker) isFromAgent(info interface{}, pAddr uint64) bool {
+	if port, ok := info.(sim.RemotePort); ok {
+		return port == c.agent.memPort.AsRemote()
+	}
+
+	_, owned := c.agent.frameToPage[pAddr>>c.agent.Log2PageSize]
+
+	return owned
+}
+
+// physicalToVirtual finds the virtual address that maps to a physical
+// address, among the pages of the agent.
+func (a *MemAccessAgent) physicalToVirtual(pAddr uint64) (uint64, bool) {
+	vPage, found := a.frameToPage[pAddr>>a.Log2PageSize]
+	if !found {
+		return 0, false
+	}
+
+	return vPage + pAddr%(1<<a.Log2PageSize), true
+}
+
+func (a *MemAccessAgent) checkTranslatedRead(req *mem.ReadReq) {
+	for i := uint64(0); i < req.AccessByteSize; i++ {
+		pAddr := req.Address + i
+
+		vAddr, found := a.physicalToVirtual(pAddr)
+		if !found {
+			a.addTranslationMismatch(req.ID, pAddr, 0, "not mapped by the agent")
+			return
+		}
+
+		if a.pendingReadBytes[vAddr] == 0 {
+			a.addTranslationMismatch(req.ID, pAddr, vAddr, "no pending read")
+			return
+		}
+	}
+}
+
+func (a *MemAccessAgent) checkTranslatedWrite(req *mem.WriteReq) {
+	for i, data := range req.Data {
+		pAddr := req.Address + uint64(i)
+
+		vAddr, found := a.physicalToVirtual(pAddr)
+		if !found {
+			a.addTranslationMismatch(req.ID, pAddr, 0, "not mapped by the agent")
+			return
+		}
+
+		if a.pendingWriteBytes[vAddr] == 0 {
+			a.addTranslationMismatch(req.ID, pAddr, vAddr, "no pending write")
+			return
+		}
+
+		values := a.Memory.legalValues(pAddr, a.Memory.firstLegalValue(pAddr))
+		if !slices.Contains(values, data) {
+			a.addTranslationMismatch(req.ID, pAddr, vAddr,
+				fmt.Sprintf("wrong data 0x%02X", data))
+			return
+		}
+	}
+}
+
+func (a *MemAccessAgent) addTranslationMismatch(
+	reqID string,
+	pAddr, vAddr uint64,
+	reason string,
+) {
+	a.TranslationMismatches = append(a.TranslationMismatches,
+		TranslationMismatch{
+			ReqID:           reqID,
+			PhysicalAddress: pAddr,
+			VirtualAddress:  vAddr,
+			Reason:          reason,
+			Time:            a.CurrentTime(),
+		})
+}
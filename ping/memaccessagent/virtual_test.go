@@ -0,0 +1,139 @@
+package memaccessagent
+
+import (
+	"testing"
+
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/mem/vm/addresstranslator"
+	"github.com/sarchlab/akita/v4/mem/vm/mmu"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/rob"
+)
+
+// runTranslatedAgent runs an agent that accesses the memory through an address
+// translator, and through a reorder buffer if withROB is set. If wrongPage is
+// set, the first two pages of the agent are swapped in the page table after
+// the agent maps them, so that their accesses are translated to the wrong
+// frames.
+func runTranslatedAgent(t *testing.T, withROB, wrongPage bool) *MemAccessAgent {
+	engine := sim.NewSerialEngine()
+	pageTable := vm.NewPageTable(12)
+
+	memCtrl := idealmemcontroller.MakeBuilder().
+		WithEngine(engine).
+		WithNewStorage(1 * mem.MB).
+		WithLatency(10).
+		Build("MemCtrl")
+
+	iommu := mmu.MakeBuilder().
+		WithEngine(engine).
+		WithLog2PageSize(12).
+		WithPageWalkingLatency(10).
+		WithPageTable(pageTable).
+		Build("MMU")
+
+	at := addresstranslator.MakeBuilder().
+		WithEngine(engine).
+		WithLog2PageSize(12).
+		WithDeviceID(1).
+		WithTranslationProviderMapperType("single").
+		WithTranslationProviders(iommu.GetPortByName("Top").AsRemote()).
+		WithMemoryProviderType("single").
+		WithMemoryProviders(memCtrl.GetPortByName("Top").AsRemote()).
+		Build("AT")
+
+	top := at.GetPortByName("Top")
+
+	connect := func(name string, ports ...sim.Port) {
+		conn := directconnection.MakeBuilder().
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build(name)
+		for _, p := range ports {
+			conn.PlugIn(p)
+		}
+	}
+
+	if withROB {
+		buffer := rob.MakeBuilder().
+			WithEngine(engine).
+			WithBufferSize(16).
+			WithBottomUnit(at.GetPortByName("Top").AsRemote()).
+			Build("ROB")
+
+		connect("ROBConn", buffer.GetPortByName("Bottom"), top)
+		top = buffer.GetPortByName("Top")
+	}
+
+	agent := MakeBuilder().
+		WithEngine(engine).
+		WithMaxAddress(16 * 4096).
+		WithWriteLeft(200).
+		WithReadLeft(200).
+		WithMaxOutstanding(1).
+		UseVirtualAddress(true).
+		WithPageTable(pageTable).
+		WithLowModule(top).
+		Build("Agent")
+
+	connect("AgentConn", agent.GetPortByName("Mem"), top)
+	connect("TranslationConn",
+		at.GetPortByName("Translation"), iommu.GetPortByName("Top"))
+	connect("MemConn",
+		at.GetPortByName("Bottom"), memCtrl.GetPortByName("Top"))
+
+	agent.CheckTranslation(at.GetPortByName("Bottom"))
+	agent.mapPages()
+
+	if wrongPage {
+		first, _ := pageTable.Find(agent.PID, agent.VirtualBase)
+		second, _ := pageTable.Find(agent.PID, agent.VirtualBase+4096)
+		first.PAddr, second.PAddr = second.PAddr, first.PAddr
+		pageTable.Update(first)
+		pageTable.Update(second)
+	}
+
+	agent.TickLater()
+
+	if err := engine.Run(); err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+
+	if err := agent.CompletionError(); err != nil {
+		t.Fatal(err)
+	}
+
+	return agent
+}
+
+func TestCheckTranslation(t *testing.T) {
+	tests := []struct {
+		name      string
+		withROB   bool
+		wrongPage bool
+	}{
+		{name: "correct page table"},
+		{name: "wrong page", wrongPage: true},
+		{name: "correct page table behind a ROB", withROB: true},
+		{name: "wrong page behind a ROB", withROB: true, wrongPage: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agent := runTranslatedAgent(t, test.withROB, test.wrongPage)
+
+			mismatches := agent.TranslationMismatches
+			if test.wrongPage && len(mismatches) == 0 {
+				t.Errorf("the wrong page is not reported")
+			}
+
+			if !test.wrongPage && len(mismatches) > 0 {
+				t.Errorf("got %d mismatches, the first one is %s",
+					len(mismatches), mismatches[0])
+			}
+		})
+	}
+}
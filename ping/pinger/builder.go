@@ -1,19 +1,28 @@
 package pinger
 
-import "github.com/sarchlab/akita/v4/sim"
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
 
 // Builder is a builder for the Ping Component.
 type Builder struct {
-	name   string
-	engine sim.Engine
-	freq   sim.Freq
+	name       string
+	engine     sim.Engine
+	freq       sim.Freq
+	extraPorts []string
+	timeout    int
+	maxRetries int
+	relayBuf   int
 }
 
 // MakeBuilder creates a new builder.
 func MakeBuilder() *Builder {
 	return &Builder{
-		name: "Pinger",
-		freq: 1 * sim.GHz,
+		name:     "Pinger",
+		freq:     1 * sim.GHz,
+		relayBuf: 4,
 	}
 }
 
@@ -35,17 +44,63 @@ func (b *Builder) WithName(name string) *Builder {
 	return b
 }
 
+// WithTimeout sets the number of cycles that the pinger waits for a response
+// before sending the request again. A timeout of 0 means the pinger waits
+// forever.
+func (b *Builder) WithTimeout(cycles int) *Builder {
+	b.timeout = cycles
+	return b
+}
+
+// WithMaxRetries sets the number of times that the pinger sends a request
+// again before it records the ping as failed.
+func (b *Builder) WithMaxRetries(n int) *Builder {
+	b.maxRetries = n
+	return b
+}
+
+// WithRelayBufferSize sets the number of messages that the pinger can hold
+// while relaying them. Once the buffer is full, the pinger stops taking
+// messages to relay from its ports until the buffered ones are forwarded. The
+// size must be positive.
+func (b *Builder) WithRelayBufferSize(n int) *Builder {
+	b.relayBuf = n
+	return b
+}
+
+// WithExtraPorts adds ports with the given names in addition to the PingPort,
+// so that the pinger can be plugged into multiple connections and relay
+// messages between them.
+func (b *Builder) WithExtraPorts(names ...string) *Builder {
+	b.extraPorts = append(b.extraPorts, names...)
+	return b
+}
+
 // Build creates a new Ping Component.
 func (b *Builder) Build(name string) *Comp {
+	if b.relayBuf <= 0 {
+		panic(fmt.Sprintf("relay buffer size %d is not positive", b.relayBuf))
+	}
+
 	c := &Comp{}
 
 	c.TickingComponent = sim.NewTickingComponent(
 		name, b.engine, b.freq, c)
 
 	c.pingProtocol = &PingProtocol{}
+	c.timeout = b.timeout
+	c.maxRetries = b.maxRetries
+	c.relayBufferSize = b.relayBuf
 
 	c.port = sim.NewPort(c, 1, 1, name+".PingPort")
 	c.AddPort("PingPort", c.port)
 
+	for _, portName := range b.extraPorts {
+		port := sim.NewPort(c, 1, 1, name+"."+portName)
+		c.AddPort(portName, port)
+	}
+
+	c.routes = make(map[string]route)
+
 	return c
 }
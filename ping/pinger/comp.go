@@ -8,9 +8,64 @@ import (
 
 type processingMsg struct {
 	pingReq   *PingReq
+	inPort    sim.Port
 	cycleLeft int
 }
 
+type outstandingPing struct {
+	req       *PingReq
+	port      sim.Port
+	sendTime  sim.VTimeInSec
+	cycleLeft int
+	attempts  int
+	needSend  bool
+}
+
+// HookPosPingComplete marks a ping issued by the pinger being responded. The
+// hook item is the PingResult.
+var HookPosPingComplete = &sim.HookPos{Name: "Ping Complete"}
+
+// HookPosPingFail marks a ping issued by the pinger being given up. The hook
+// item is the PingFailure.
+var HookPosPingFail = &sim.HookPos{Name: "Ping Fail"}
+
+// PingResult records a ping issued by a pinger that has received its response.
+type PingResult struct {
+	ReqID      string
+	Target     string
+	SendTime   sim.VTimeInSec
+	ReturnTime sim.VTimeInSec
+	ReqHops    int
+	RspHops    int
+	Attempts   int
+}
+
+// Latency returns the round trip time of the ping.
+func (r PingResult) Latency() sim.VTimeInSec {
+	return r.ReturnTime - r.SendTime
+}
+
+// PerHopLatency returns the round trip time divided by the number of links
+// that the request and the response traversed.
+func (r PingResult) PerHopLatency() sim.VTimeInSec {
+	hops := r.ReqHops + r.RspHops
+	if hops == 0 {
+		return 0
+	}
+
+	return r.Latency() / sim.VTimeInSec(hops)
+}
+
+// PingFailure records a ping that has not been responded after the pinger
+// exhausted all the retransmissions.
+type PingFailure struct {
+	ReqID    string
+	Target   string
+	SendTime sim.VTimeInSec
+	FailTime sim.VTimeInSec
+	Attempts int
+}
+
 // Comp is the simulation component that can send and respond to ping messages.
 type Comp struct {
 	*sim.TickingComponent
@@ -18,9 +73,35 @@ type Comp struct {
 	port         sim.Port
 	pingProtocol *PingProtocol
 
-	latency int
+	latency         int
+	timeout         int
+	maxRetries      int
+	relayBufferSize int
+
+	processingMsgs   []*processingMsg
+	relayingMsgs     []*relayingMsg
+	outstandingPings []*outstandingPing
+	routes           map[string]route
+
+	// NumRelayed is the number of messages that the pinger forwarded on
+	// behalf of other pingers.
+	NumRelayed int
+
+	// NumUnroutable is the number of messages that the pinger dropped
+	// because it has no route to their target.
+	NumUnroutable int
+
+	// CompletedPings lists the pings issued by this pinger that have been
+	// responded.
+	CompletedPings []PingResult
 
-	processingMsgs []*processingMsg
+	// NumRetransmitted is the number of requests that the pinger sent again
+	// because the response did not arrive in time.
+	NumRetransmitted int
+
+	// FailedPings lists the pings issued by this pinger that have never been
+	// responded.
+	FailedPings []PingFailure
 }
 
 func (c *Comp) Handle(e sim.Event) error {
@@ -35,17 +116,35 @@ func (c *Comp) Handle(e sim.Event) error {
 }
 
 func (c *Comp) handlePingEvent(e *PingEvent) error {
-	pingReq, err := c.pingProtocol.CreateMsg("PingReq")
+	msg, err := c.pingProtocol.CreateMsg("PingReq")
 	if err != nil {
 		return err
 	}
 
-	pingReq.Meta().Src = c.port.AsRemote()
+	pingReq := msg.(*PingReq)
+	pingReq.Origin = c.Name()
+	pingReq.Target = e.dst.Name()
+	pingReq.HopCount = 1
+
+	port := c.port
 	pingReq.Meta().Dst = e.dst.GetPortByName("PingPort").AsRemote()
+	if r, found := c.findRoute(e.dst.Name()); found {
+		port = r.port
+		pingReq.Meta().Dst = r.nextHop
+	}
+	pingReq.Meta().Src = port.AsRemote()
 
-	sendError := c.port.Send(pingReq)
-	if sendError != nil {
-		panic(sendError)
+	p := &outstandingPing{
+		req:      pingReq,
+		port:     port,
+		sendTime: e.Time(),
+		needSend: true,
+	}
+	c.outstandingPings = append(c.outstandingPings, p)
+
+	c.sendPing(p)
+	if p.needSend || c.timeout > 0 {
+		c.TickLater()
 	}
 
 	return nil
@@ -53,6 +152,9 @@ func (c *Comp) handlePingEvent(e *PingEvent) error {
 
 // Tick updates the component state
 func (c *Comp) Tick() (madeProgress bool) {
+	madeProgress = c.forward() || madeProgress
+	madeProgress = c.checkTimeouts() || madeProgress
+	madeProgress = c.sendPings() || madeProgress
 	madeProgress = c.respond() || madeProgress
 	madeProgress = c.update() || madeProgress
 	madeProgress = c.receive() || madeProgress
@@ -61,26 +163,170 @@ func (c *Comp) Tick() (madeProgress bool) {
 }
 
 func (c *Comp) receive() bool {
-	msg := c.port.RetrieveIncoming()
+	madeProgress := false
+
+	for _, port := range c.Ports() {
+		madeProgress = c.receiveFrom(port) || madeProgress
+	}
+
+	return madeProgress
+}
+
+func (c *Comp) receiveFrom(port sim.Port) bool {
+	msg := port.PeekIncoming()
 	if msg == nil {
 		return false
 	}
 
 	switch msg := msg.(type) {
 	case *PingReq:
+		if msg.Target != "" && msg.Target != c.Name() {
+			return c.relayFrom(port, msg, msg.Target)
+		}
+
+		port.RetrieveIncoming()
 		c.processingMsgs = append(c.processingMsgs, &processingMsg{
 			pingReq:   msg,
+			inPort:    port,
 			cycleLeft: c.latency,
 		})
 
 		return true
-	case *sim.GeneralRsp:
+	case *PingRsp:
+		if msg.Target != "" && msg.Target != c.Name() {
+			return c.relayFrom(port, msg, msg.Target)
+		}
+
+		port.RetrieveIncoming()
+		c.completePing(msg)
+
 		return true
 	default:
 		panic("Unknown message type")
 	}
 }
 
+func (c *Comp) completePing(rsp *PingRsp) {
+	for i, p := range c.outstandingPings {
+		if p.req.Meta().ID != rsp.RespondTo {
+			continue
+		}
+
+		c.outstandingPings = append(
+			c.outstandingPings[:i], c.outstandingPings[i+1:]...)
+
+		result := PingResult{
+			ReqID:      rsp.RespondTo,
+			Target:     rsp.Origin,
+			SendTime:   p.sendTime,
+			ReturnTime: c.CurrentTime(),
+			ReqHops:    rsp.ReqHopCount,
+			RspHops:    rsp.HopCount,
+			Attempts:   p.attempts,
+		}
+		c.CompletedPings = append(c.CompletedPings, result)
+
+		c.InvokeHook(sim.HookCtx{
+			Domain: c,
+			Pos:    HookPosPingComplete,
+			Item:   result,
+		})
+
+		return
+	}
+
+	// Responses to pings that have already completed or failed are dropped.
+}
+
+// sendPings sends the requests that are issued or timed out but could not be
+// sent because the port was busy.
+func (c *Comp) sendPings() bool {
+	madeProgress := false
+
+	for _, p := range c.outstandingPings {
+		if p.needSend {
+			madeProgress = c.sendPing(p) || madeProgress
+		}
+	}
+
+	return madeProgress
+}
+
+func (c *Comp) sendPing(p *outstandingPing) bool {
+	req := *p.req
+	req.HopCount = 1
+
+	err := p.port.Send(&req)
+	if err != nil {
+		return false
+	}
+
+	p.needSend = false
+	p.cycleLeft = c.timeout
+	p.attempts++
+
+	if p.attempts > 1 {
+		c.NumRetransmitted++
+	}
+
+	return true
+}
+
+// checkTimeouts counts down the outstanding pings and retransmits the ones
+// that are not responded in time.
+func (c *Comp) checkTimeouts() bool {
+	if c.timeout == 0 {
+		return false
+	}
+
+	madeProgress := false
+
+	for i := 0; i < len(c.outstandingPings); i++ {
+		p := c.outstandingPings[i]
+		if p.needSend {
+			continue
+		}
+
+		p.cycleLeft--
+		madeProgress = true
+
+		if p.cycleLeft > 0 {
+			continue
+		}
+
+		if p.attempts > c.maxRetries {
+			c.failPing(p)
+			c.outstandingPings = append(
+				c.outstandingPings[:i], c.outstandingPings[i+1:]...)
+			i--
+
+			continue
+		}
+
+		p.needSend = true
+		c.sendPing(p)
+	}
+
+	return madeProgress
+}
+
+func (c *Comp) failPing(p *outstandingPing) {
+	failure := PingFailure{
+		ReqID:    p.req.Meta().ID,
+		Target:   p.req.Target,
+		SendTime: p.sendTime,
+		FailTime: c.CurrentTime(),
+		Attempts: p.attempts,
+	}
+	c.FailedPings = append(c.FailedPings, failure)
+
+	c.InvokeHook(sim.HookCtx{
+		Domain: c,
+		Pos:    HookPosPingFail,
+		Item:   failure,
+	})
+}
+
 func (c *Comp) update() bool {
 	madeProgress := false
 
@@ -106,13 +352,30 @@ func (c *Comp) respond() bool {
 			continue
 		}
 
-		rsp := sim.GeneralRspBuilder{}.
-			WithSrc(c.port.AsRemote()).
-			WithDst(msg.pingReq.Meta().Src).
-			WithOriginalReq(msg.pingReq).
-			Build()
+		rsp := &PingRsp{
+			MsgMeta: sim.MsgMeta{
+				ID: sim.GetIDGenerator().Generate(),
+			},
+			RespondTo:   msg.pingReq.Meta().ID,
+			Origin:      c.Name(),
+			Target:      msg.pingReq.Origin,
+			HopCount:    1,
+			ReqHopCount: msg.pingReq.HopCount,
+		}
+
+		port := msg.inPort
+		rsp.Meta().Dst = msg.pingReq.Meta().Src
+		if r, found := c.findRoute(msg.pingReq.Origin); found {
+			port = r.port
+			rsp.Meta().Dst = r.nextHop
+		}
+		rsp.Meta().Src = port.AsRemote()
+
+		err := port.Send(rsp)
+		if err != nil {
+			continue
+		}
 
-		c.port.Send(rsp)
 		c.processingMsgs = append(
 			c.processingMsgs[:i], c.processingMsgs[i+1:]...)
 		i--
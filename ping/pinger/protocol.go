@@ -20,7 +20,7 @@ func (p *PingProtocol) CreateMsg(msgType string) (sim.Msg, error) {
 			},
 		}, nil
 	case "PingRsp":
-		return &sim.GeneralRsp{
+		return &PingRsp{
 			MsgMeta: sim.MsgMeta{
 				ID: sim.GetIDGenerator().Generate(),
 			},
@@ -33,6 +33,17 @@ func (p *PingProtocol) CreateMsg(msgType string) (sim.Msg, error) {
 // PingReq is the request message for the ping protocol
 type PingReq struct {
 	MsgMeta sim.MsgMeta
+
+	// Origin is the name of the pinger that issued the request.
+	Origin string
+
+	// Target is the name of the pinger that should respond. Pingers that are
+	// not the target relay the request toward it. An empty target means the
+	// first pinger that receives the request responds.
+	Target string
+
+	// HopCount is the number of links the request has traversed.
+	HopCount int
 }
 
 // Meta returns the meta data associated with the message
@@ -43,3 +54,35 @@ func (m *PingReq) Meta() *sim.MsgMeta {
 func (m *PingReq) Clone() sim.Msg {
 	return m
 }
+
+// PingRsp is the response message for the ping protocol
+type PingRsp struct {
+	MsgMeta sim.MsgMeta
+
+	// RespondTo is the ID of the request that this response answers.
+	RespondTo string
+
+	// Origin is the name of the pinger that responded and Target is the name
+	// of the pinger that issued the request.
+	Origin string
+	Target string
+
+	// HopCount is the number of links the response has traversed, and
+	// ReqHopCount is the number of links the request traversed.
+	HopCount    int
+	ReqHopCount int
+}
+
+// Meta returns the meta data associated with the message
+func (m *PingRsp) Meta() *sim.MsgMeta {
+	return &m.MsgMeta
+}
+
+func (m *PingRsp) Clone() sim.Msg {
+	return m
+}
+
+// GetRspTo returns the ID of the request that the response answers.
+func (m *PingRsp) GetRspTo() string {
+	return m.RespondTo
+}
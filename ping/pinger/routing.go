@@ -0,0 +1,109 @@
+package pinger
+
+import (
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// A route tells a pinger how to reach another pinger.
+type route struct {
+	port    sim.Port
+	nextHop sim.RemotePort
+}
+
+type relayingMsg struct {
+	msg  sim.Msg
+	port sim.Port
+}
+
+// AddRoute makes the pinger send messages destined to the pinger named dst out
+// of its local port named portName, toward the neighbor port nextHop. The
+// neighbor can be the destination itself or another pinger that relays the
+// message further.
+func (c *Comp) AddRoute(dst string, portName string, nextHop sim.RemotePort) {
+	c.routes[dst] = route{
+		port:    c.GetPortByName(portName),
+		nextHop: nextHop,
+	}
+}
+
+func (c *Comp) findRoute(dst string) (route, bool) {
+	r, found := c.routes[dst]
+	return r, found
+}
+
+// relayFrom takes a message that is not addressed to this pinger from a port
+// and queues it for relaying. The message is left in the port, applying
+// backpressure to the sender, if the relay buffer is full. A message toward a
+// target that the pinger has no route to is dropped, so that the ping times
+// out and fails at its origin.
+func (c *Comp) relayFrom(port sim.Port, msg sim.Msg, target string) bool {
+	r, found := c.findRoute(target)
+	if !found {
+		port.RetrieveIncoming()
+		c.NumUnroutable++
+
+		return true
+	}
+
+	if len(c.relayingMsgs) >= c.relayBufferSize {
+		return false
+	}
+
+	port.RetrieveIncoming()
+	c.relay(msg, r)
+
+	return true
+}
+
+// relay queues a message that is not addressed to this pinger so that it can
+// be forwarded along a route toward its target.
+func (c *Comp) relay(msg sim.Msg, r route) {
+	var fwd sim.Msg
+	switch msg := msg.(type) {
+	case *PingReq:
+		m := *msg
+		m.HopCount++
+		fwd = &m
+	case *PingRsp:
+		m := *msg
+		m.HopCount++
+		fwd = &m
+	default:
+		panic("Unknown message type")
+	}
+
+	fwd.Meta().Src = r.port.AsRemote()
+	fwd.Meta().Dst = r.nextHop
+
+	c.relayingMsgs = append(c.relayingMsgs, &relayingMsg{
+		msg:  fwd,
+		port: r.port,
+	})
+	c.NumRelayed++
+}
+
+// forward sends the relayed messages. Messages that go out of the same port
+// leave in the order they arrived.
+func (c *Comp) forward() bool {
+	madeProgress := false
+	blockedPorts := make(map[sim.Port]bool)
+
+	for i := 0; i < len(c.relayingMsgs); i++ {
+		m := c.relayingMsgs[i]
+		if blockedPorts[m.port] {
+			continue
+		}
+
+		err := m.port.Send(m.msg)
+		if err != nil {
+			blockedPorts[m.port] = true
+			continue
+		}
+
+		c.relayingMsgs = append(c.relayingMsgs[:i], c.relayingMsgs[i+1:]...)
+		i--
+		madeProgress = true
+	}
+
+	return madeProgress
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/ping/benchmarks/all_to_all_ping"
+	"github.com/sarchlab/yuzawa_example/ping/pinger"
+)
+
+func main() {
+	s := simulation.MakeBuilder().Build()
+	engine := s.GetEngine()
+
+	conn := directconnection.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Conn")
+
+	// Create four pingers that share one connection
+	names := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("Pinger%d", i)
+		p := pinger.MakeBuilder().
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build(name)
+		s.RegisterComponent(p)
+		conn.PlugIn(p.GetPortByName("PingPort"))
+
+		names = append(names, name)
+	}
+
+	benchmark := all_to_all_ping.MakeBuilder().
+		WithSimulation(s).
+		WithPingers(names).
+		WithNumPings(10).
+		WithWindow(2).
+		Build("Benchmark")
+
+	benchmark.Run()
+}
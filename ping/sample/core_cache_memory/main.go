@@ -48,12 +48,15 @@ func main() {
 	s.RegisterComponent(L1Cache)
 
 	MemAgent := memaccessagent.MakeBuilder().
-		WithFreq(1 * sim.GHz).
-		WithMaxAddress(1 * mem.GB).
+		WithFreq(1*sim.GHz).
+		WithMaxAddress(1*mem.GB).
 		WithWriteLeft(100000).
 		WithReadLeft(100000).
 		WithEngine(engine).
 		WithLowModule(L1Cache.GetPortByName("Top")).
+		WithControlTargets(
+			L1Cache.GetPortByName("Control"),
+			L2Cache.GetPortByName("Control")).
 		Build("MemAgent")
 	s.RegisterComponent(MemAgent)
 
@@ -69,6 +72,11 @@ func main() {
 	Conn3.PlugIn(L2Cache.GetPortByName("Bottom"))
 	Conn3.PlugIn(MemCtrl.GetPortByName("Top"))
 
+	CtrlConn := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("CtrlConn")
+	CtrlConn.PlugIn(MemAgent.GetPortByName("Control"))
+	CtrlConn.PlugIn(L1Cache.GetPortByName("Control"))
+	CtrlConn.PlugIn(L2Cache.GetPortByName("Control"))
+
 	traceFile, err := os.Create("trace.log")
 	if err != nil {
 		panic("Error: Failed to create trace file")
@@ -77,10 +85,19 @@ func main() {
 	tracer := trace.NewTracer(logger, engine)
 	tracing.CollectTrace(MemCtrl, tracer)
 
+	config := memaccessagent.DefaultConfig()
+	config.NumAccess = 100000
+	config.MaxAddress = 1 * mem.GB
+	config.FlushRate = 0.001
+	config.InvalidateRate = 0.001
+	config.PauseRate = 0.001
+	config.PauseCycles = 100
+
 	benchmark := ideal_mem_controller.MakeBuilder().
 		WithSimulation(s).
-		WithNumAccess(100000).
-		WithMaxAddress(1 * mem.GB).
+		WithAgentConfig(config).
 		Build("Benchmark")
-	benchmark.Run()
+	if err := benchmark.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
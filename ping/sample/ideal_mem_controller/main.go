@@ -10,8 +10,8 @@ import (
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/trace"
 	"github.com/sarchlab/akita/v4/sim"
-	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/akita/v4/sim/directconnection"
+	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/akita/v4/tracing"
 
 	"github.com/sarchlab/yuzawa_example/ping/benchmarks/ideal_mem_controller"
@@ -73,5 +73,7 @@ func main() {
 		WithNumAccess(100000).
 		WithMaxAddress(1 * mem.GB).
 		Build("Benchmark")
-	benchmark.Run()
+	if err := benchmark.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sarchlab/akita/v4/mem/cache/writeback"
+	"github.com/sarchlab/akita/v4/mem/cache/writethrough"
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/ping/benchmarks/ideal_mem_controller"
+	"github.com/sarchlab/yuzawa_example/ping/memaccessagent"
+)
+
+type point struct {
+	interval  int
+	bandwidth float64
+	latency   sim.VTimeInSec
+}
+
+func main() {
+	// Sweep the issue interval from a light load to a saturating load and
+	// measure the latency of the cache hierarchy at each point.
+	intervals := []int{64, 32, 16, 8, 4, 2, 1}
+	points := make([]point, 0, len(intervals))
+
+	for _, interval := range intervals {
+		agent := run(interval)
+		points = append(points, point{
+			interval:  interval,
+			bandwidth: agent.Bandwidth(),
+			latency:   agent.AverageLatency(),
+		})
+	}
+
+	fmt.Printf("\n%10s %18s %14s\n",
+		"interval", "bandwidth (GB/s)", "latency (ns)")
+	for _, p := range points {
+		fmt.Printf("%10d %18.3f %14.2f\n",
+			p.interval, p.bandwidth/1e9, float64(p.latency)*1e9)
+	}
+}
+
+func run(interval int) *memaccessagent.MemAccessAgent {
+	s := simulation.MakeBuilder().WithoutMonitoring().Build()
+	defer s.Terminate()
+
+	engine := s.GetEngine()
+
+	MemCtrl := idealmemcontroller.MakeBuilder().
+		WithEngine(engine).
+		WithNewStorage(4 * mem.GB).
+		WithLatency(100).
+		Build("MemCtrl")
+	s.RegisterComponent(MemCtrl)
+
+	L2Cache := writeback.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWayAssociativity(4).
+		WithNumReqPerCycle(2).
+		WithAddressMapperType("single").
+		WithRemotePorts(MemCtrl.GetPortByName("Top").AsRemote()).
+		Build("L2Cache")
+	s.RegisterComponent(L2Cache)
+
+	L1Cache := writethrough.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWayAssociativity(2).
+		WithAddressMapperType("single").
+		WithRemotePorts(L2Cache.GetPortByName("Top").AsRemote()).
+		Build("L1Cache")
+	s.RegisterComponent(L1Cache)
+
+	MemAgent := memaccessagent.MakeBuilder().
+		WithFreq(1 * sim.GHz).
+		WithEngine(engine).
+		WithLowModule(L1Cache.GetPortByName("Top")).
+		Build("MemAgent")
+	s.RegisterComponent(MemAgent)
+
+	Conn1 := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("Conn1")
+	Conn1.PlugIn(MemAgent.GetPortByName("Mem"))
+	Conn1.PlugIn(L1Cache.GetPortByName("Top"))
+
+	Conn2 := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("Conn2")
+	Conn2.PlugIn(L1Cache.GetPortByName("Bottom"))
+	Conn2.PlugIn(L2Cache.GetPortByName("Top"))
+
+	Conn3 := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("Conn3")
+	Conn3.PlugIn(L2Cache.GetPortByName("Bottom"))
+	Conn3.PlugIn(MemCtrl.GetPortByName("Top"))
+
+	config := memaccessagent.DefaultConfig()
+	config.NumAccess = 5000
+	config.MaxAddress = 1 * mem.MB
+	config.MaxOutstanding = 64
+	config.IssueInterval = interval
+	config.Warmup = 500
+
+	benchmark := ideal_mem_controller.MakeBuilder().
+		WithSimulation(s).
+		WithAgentConfig(config).
+		Build("Benchmark")
+	if err := benchmark.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	return MemAgent
+}
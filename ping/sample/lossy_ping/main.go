@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/ping/benchmarks/multi_ping"
+	"github.com/sarchlab/yuzawa_example/ping/lossyconnection"
+	"github.com/sarchlab/yuzawa_example/ping/pinger"
+)
+
+func main() {
+	s := simulation.MakeBuilder().Build()
+	engine := s.GetEngine()
+
+	// The sender waits 20 cycles for each response and retries 3 times.
+	pingBuilder := pinger.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithTimeout(20).
+		WithMaxRetries(3)
+	sender := pingBuilder.Build("Sender")
+	s.RegisterComponent(sender)
+
+	pingBuilder = pinger.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz)
+	receiver := pingBuilder.Build("Receiver")
+	s.RegisterComponent(receiver)
+
+	// Create a connection that loses 30% of the messages
+	conn := lossyconnection.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithDropRate(0.3).
+		WithSeed(1).
+		Build("Conn")
+	conn.PlugIn(sender.GetPortByName("PingPort"))
+	conn.PlugIn(receiver.GetPortByName("PingPort"))
+
+	benchmarkBuilder := multi_ping.MakeBuilder().
+		WithSimulation(s).
+		WithSenders([]string{"Sender"}).
+		WithReceiver("Receiver").
+		WithNumPings(10)
+	benchmark := benchmarkBuilder.Build("Benchmark")
+
+	benchmark.Run()
+
+	fmt.Printf("Dropped %d of %d messages, retransmitted %d requests\n",
+		conn.NumDropped, conn.NumDropped+conn.NumDelivered,
+		sender.NumRetransmitted)
+}
@@ -115,12 +115,19 @@ func main() {
 	// }
 
 	MemAgent := memaccessagent.MakeBuilder().
-		WithFreq(1 * sim.GHz).
-		WithMaxAddress(1 * mem.GB).
+		WithFreq(1*sim.GHz).
+		WithMaxAddress(1*mem.GB).
 		WithWriteLeft(100000).
 		WithReadLeft(100000).
 		WithEngine(engine).
-		WithLowModule(L1Cache.GetPortByName("Top")).
+		UseVirtualAddress(true).
+		WithPageTable(pageTable).
+		WithLog2PageSize(12).
+		WithPhysicalPlacement(0, "random").
+		WithLowModule(ROB.GetPortByName("Top")).
+		WithControlTargets(
+			L1Cache.GetPortByName("Control"),
+			L2Cache.GetPortByName("Control")).
 		Build("MemAgent")
 	s.RegisterComponent(MemAgent)
 
@@ -156,6 +163,11 @@ func main() {
 	Conn8.PlugIn(L2Cache.GetPortByName("Bottom"))
 	Conn8.PlugIn(MemCtrl.GetPortByName("Top"))
 
+	CtrlConn := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("CtrlConn")
+	CtrlConn.PlugIn(MemAgent.GetPortByName("Control"))
+	CtrlConn.PlugIn(L1Cache.GetPortByName("Control"))
+	CtrlConn.PlugIn(L2Cache.GetPortByName("Control"))
+
 	traceFile, err := os.Create("trace.log")
 	if err != nil {
 		panic("Error: Failed to create trace file")
@@ -164,10 +176,20 @@ func main() {
 	tracer := trace.NewTracer(logger, engine)
 	tracing.CollectTrace(MemCtrl, tracer)
 
+	config := memaccessagent.DefaultConfig()
+	config.NumAccess = 100000
+	config.MaxAddress = 1 * mem.GB
+	config.FlushRate = 0.001
+	config.InvalidateRate = 0.001
+	config.PauseRate = 0.001
+	config.PauseCycles = 100
+
 	benchmark := multi_stage_memory.MakeBuilder().
 		WithSimulation(s).
-		WithNumAccess(100000).
-		WithMaxAddress(1 * mem.GB).
+		WithAgentConfig(config).
+		WithAddressTranslatorName("AT").
 		Build("Benchmark")
-	benchmark.Run()
+	if err := benchmark.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/ping/benchmarks/multi_ping"
+	"github.com/sarchlab/yuzawa_example/ping/pinger"
+)
+
+func main() {
+	s := simulation.MakeBuilder().Build()
+	engine := s.GetEngine()
+
+	// Create a chain of pingers: Sender - Relay1 - Relay2 - Receiver
+	pingBuilder := pinger.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz)
+	sender := pingBuilder.Build("Sender")
+	s.RegisterComponent(sender)
+
+	receiver := pingBuilder.Build("Receiver")
+	s.RegisterComponent(receiver)
+
+	relayBuilder := pinger.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithExtraPorts("Forward")
+	relay1 := relayBuilder.Build("Relay1")
+	s.RegisterComponent(relay1)
+
+	relay2 := relayBuilder.Build("Relay2")
+	s.RegisterComponent(relay2)
+
+	// Create connections between neighbors
+	conn1 := directconnection.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Conn1")
+	conn1.PlugIn(sender.GetPortByName("PingPort"))
+	conn1.PlugIn(relay1.GetPortByName("PingPort"))
+
+	conn2 := directconnection.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Conn2")
+	conn2.PlugIn(relay1.GetPortByName("Forward"))
+	conn2.PlugIn(relay2.GetPortByName("PingPort"))
+
+	conn3 := directconnection.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Conn3")
+	conn3.PlugIn(relay2.GetPortByName("Forward"))
+	conn3.PlugIn(receiver.GetPortByName("PingPort"))
+
+	// Set up the routing tables
+	sender.AddRoute("Receiver", "PingPort",
+		relay1.GetPortByName("PingPort").AsRemote())
+	relay1.AddRoute("Receiver", "Forward",
+		relay2.GetPortByName("PingPort").AsRemote())
+	relay1.AddRoute("Sender", "PingPort",
+		sender.GetPortByName("PingPort").AsRemote())
+	relay2.AddRoute("Receiver", "Forward",
+		receiver.GetPortByName("PingPort").AsRemote())
+	relay2.AddRoute("Sender", "PingPort",
+		relay1.GetPortByName("Forward").AsRemote())
+	receiver.AddRoute("Sender", "PingPort",
+		relay2.GetPortByName("Forward").AsRemote())
+
+	benchmarkBuilder := multi_ping.MakeBuilder().
+		WithSimulation(s).
+		WithSenders([]string{"Sender"}).
+		WithReceiver("Receiver").
+		WithNumPings(5)
+	benchmark := benchmarkBuilder.Build("Benchmark")
+
+	benchmark.Run()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sarchlab/akita/v4/mem/cache/writeback"
+	"github.com/sarchlab/akita/v4/mem/cache/writethrough"
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/sim/directconnection"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/yuzawa_example/ping/benchmarks/shared_memory"
+	"github.com/sarchlab/yuzawa_example/ping/memaccessagent"
+)
+
+const numAgents = 2
+
+func main() {
+	// Each agent has a private write-through L1 cache, and the agents share a
+	// write-back L2 cache. The L1 caches are not coherent, so the agents can
+	// read stale data from the shared range, but never from their private
+	// ranges or from false-shared lines.
+	for _, mode := range []string{"private", "false_sharing", "mixed", "shared"} {
+		fmt.Printf("\nSharing mode: %s\n", mode)
+		run(mode)
+	}
+}
+
+func run(mode string) {
+	s := simulation.MakeBuilder().WithoutMonitoring().Build()
+	defer s.Terminate()
+
+	engine := s.GetEngine()
+
+	MemCtrl := idealmemcontroller.MakeBuilder().
+		WithEngine(engine).
+		WithNewStorage(4 * mem.GB).
+		WithLatency(100).
+		Build("MemCtrl")
+	s.RegisterComponent(MemCtrl)
+
+	L2Cache := writeback.MakeBuilder().
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWayAssociativity(4).
+		WithNumReqPerCycle(2).
+		WithAddressMapperType("single").
+		WithRemotePorts(MemCtrl.GetPortByName("Top").AsRemote()).
+		Build("L2Cache")
+	s.RegisterComponent(L2Cache)
+
+	L2Conn := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("L2Conn")
+	L2Conn.PlugIn(L2Cache.GetPortByName("Top"))
+
+	MemConn := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build("MemConn")
+	MemConn.PlugIn(L2Cache.GetPortByName("Bottom"))
+	MemConn.PlugIn(MemCtrl.GetPortByName("Top"))
+
+	golden := memaccessagent.NewGoldenMemory()
+	agentNames := make([]string, 0, numAgents)
+
+	for i := 0; i < numAgents; i++ {
+		L1Cache := writethrough.MakeBuilder().
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWayAssociativity(2).
+			WithAddressMapperType("single").
+			WithRemotePorts(L2Cache.GetPortByName("Top").AsRemote()).
+			Build(fmt.Sprintf("L1Cache%d", i))
+		s.RegisterComponent(L1Cache)
+
+		name := fmt.Sprintf("MemAgent%d", i)
+		MemAgent := memaccessagent.MakeBuilder().
+			WithFreq(1 * sim.GHz).
+			WithEngine(engine).
+			WithGoldenMemory(golden).
+			WithLowModule(L1Cache.GetPortByName("Top")).
+			Build(name)
+		s.RegisterComponent(MemAgent)
+		agentNames = append(agentNames, name)
+
+		Conn := directconnection.MakeBuilder().WithEngine(engine).WithFreq(1 * sim.GHz).Build(fmt.Sprintf("Conn%d", i))
+		Conn.PlugIn(MemAgent.GetPortByName("Mem"))
+		Conn.PlugIn(L1Cache.GetPortByName("Top"))
+
+		L2Conn.PlugIn(L1Cache.GetPortByName("Bottom"))
+	}
+
+	benchmark := shared_memory.MakeBuilder().
+		WithSimulation(s).
+		WithAgents(agentNames).
+		WithNumAccess(5000).
+		WithMaxAddress(64 * mem.KB).
+		WithSharing(mode).
+		WithStaleReadsAllowed(true).
+		Build("Benchmark")
+	if err := benchmark.Run(); err != nil {
+		log.Fatal(err)
+	}
+}